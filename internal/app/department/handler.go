@@ -2,8 +2,13 @@ package department
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
 	"net/http"
+	"template-golang/internal/app/etag"
+	"template-golang/internal/app/httperror"
+	"template-golang/internal/app/patch"
+	"template-golang/internal/app/request"
 	"template-golang/internal/domain/department"
 )
 
@@ -20,38 +25,75 @@ func NewHandler(service *Service) *Handler {
 // @Tags department
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} Department
+// @Success 201 {object} Department
+// @Failure 409 {string} string "Department already exists"
 // @Router /departments [post]
 func (h *Handler) CreateDepartment(w http.ResponseWriter, r *http.Request) {
 	var emp department.Department
-	if err := json.NewDecoder(r.Body).Decode(&emp); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := request.DecodeJSON(r, &emp); err != nil {
+		httperror.BadRequest(w, r, "Invalid request body: "+err.Error())
 		return
 	}
 
-	h.service.CreateDepartment(emp)
+	created, err := h.service.CreateDepartment(r.Context(), emp)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", Departments.Location(created.ID))
+	w.Header().Set("ETag", etag.Format(created.Version))
 	w.WriteHeader(http.StatusCreated)
-	err := json.NewEncoder(w).Encode(emp)
+	err = json.NewEncoder(w).Encode(created)
 	if err != nil {
 		return
 	}
 }
 
 // @Summary Get Departments
-// @Description get list of departments
+// @Description get a page of departments
 // @Tags departments
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} Department
+// @Param limit query int false "Page size"
+// @Param after query string false "Cursor returned as next_cursor by the previous page"
+// @Param sort query string false "Sort order: id or name"
+// @Param name_prefix query string false "Only departments whose name starts with this prefix"
+// @Param expand query string false "Related records to embed: head"
+// @Success 200 {object} listing.Page[Department]
 // @Router /departments [get]
 func (h *Handler) GetAllDepartments(w http.ResponseWriter, r *http.Request) {
-	departments, err := h.service.GetAllDepartments()
+	q, err := request.ListQuery(r, department.Sorts)
+	if err != nil {
+		httperror.BadRequest(w, r, err.Error())
+		return
+	}
+	expand, err := request.Expand(r, department.Expansions)
+	if err != nil {
+		httperror.BadRequest(w, r, err.Error())
+		return
+	}
+
+	page, err := h.service.ListDepartments(r.Context(), department.ListQuery{
+		Query:      q,
+		NamePrefix: r.URL.Query().Get("name_prefix"),
+	})
 	if err != nil {
-		http.Error(w, "Failed to retrieve departments", http.StatusInternalServerError)
+		httperror.Write(w, r, err)
 		return
 	}
-	err = json.NewEncoder(w).Encode(departments)
+
+	var body any = page
+	if len(expand) > 0 {
+		if body, err = h.service.ExpandPage(r.Context(), page, expand); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(body)
 	if err != nil {
 		return
 	}
@@ -63,24 +105,45 @@ func (h *Handler) GetAllDepartments(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "ID"
+// @Param If-None-Match header string false "ETag of the cached version, ignored when expanding"
+// @Param expand query string false "Related records to embed: head"
 // @Success 200 {array} Department
 // @Router /departments/{id} [get]
 func (h *Handler) GetDepartmentById(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Department ID is required", http.StatusBadRequest)
+		httperror.BadRequest(w, r, "Department ID is required")
+		return
+	}
+	expand, err := request.Expand(r, department.Expansions)
+	if err != nil {
+		httperror.BadRequest(w, r, err.Error())
 		return
 	}
 
-	department, err := h.service.GetDepartmentByID(id)
+	dept, err := h.service.GetDepartmentByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Department not found", http.StatusNotFound)
+		httperror.Write(w, r, err)
+		return
+	}
+
+	// The ETag is the version of the department alone, so it cannot tell
+	// whether an embedded record changed.
+	w.Header().Set("ETag", etag.Format(dept.Version))
+	var body any = dept
+	if len(expand) > 0 {
+		if body, err = h.service.Expand(r.Context(), *dept, expand); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+	} else if etag.NoneMatch(r, dept.Version) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(department)
+	err = json.NewEncoder(w).Encode(body)
 	if err != nil {
 		return
 	}
@@ -92,31 +155,78 @@ func (h *Handler) GetDepartmentById(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "ID"
+// @Param If-Match header string false "ETag of the version being replaced"
 // @Created 201
 // @Router /departments/{id] [put]
 func (h *Handler) UpdateDepartmentByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Department ID is required", http.StatusBadRequest)
+		httperror.BadRequest(w, r, "Department ID is required")
 		return
 	}
 
 	var emp department.Department
-	if err := json.NewDecoder(r.Body).Decode(&emp); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := request.DecodeJSON(r, &emp); err != nil {
+		httperror.BadRequest(w, r, "Invalid request body: "+err.Error())
 		return
 	}
 
 	// Set the Department ID from the URL parameter to ensure consistency
 	emp.ID = id
-	if err := h.service.UpdateDepartmentByID(id, emp); err != nil {
-		http.Error(w, "Department not found", http.StatusNotFound)
+	updated, err := h.service.UpdateDepartmentByID(r.Context(), id, etag.IfMatch(r), emp)
+	if err != nil {
+		httperror.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag.Format(updated.Version))
 	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(emp)
+	err = json.NewEncoder(w).Encode(updated)
+	if err != nil {
+		return
+	}
+}
+
+// @Summary Patch Department
+// @Description partially update department with a JSON Merge Patch or a JSON Patch
+// @Tags department
+// @Accept  application/merge-patch+json
+// @Accept  application/json-patch+json
+// @Produce  json
+// @Param id path string true "ID"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Success 200 {object} Department
+// @Router /departments/{id} [patch]
+func (h *Handler) PatchDepartmentByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		httperror.BadRequest(w, r, "Department ID is required")
+		return
+	}
+
+	p, err := request.Patch(r)
+	if errors.Is(err, patch.ErrUnsupportedMediaType) {
+		w.Header().Set("Accept-Patch", patch.Accepted)
+		httperror.Status(w, r, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	if err != nil {
+		httperror.BadRequest(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+
+	patched, err := h.service.PatchDepartmentByID(r.Context(), id, etag.IfMatch(r), p)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag.Format(patched.Version))
+	err = json.NewEncoder(w).Encode(patched)
 	if err != nil {
 		return
 	}
@@ -127,20 +237,113 @@ func (h *Handler) UpdateDepartmentByID(w http.ResponseWriter, r *http.Request) {
 // @Tags department
 // @Accept  json
 // @Param id path string true "ID"
+// @Param policy query string false "What to do with the department employees: reject, cascade or reassign"
+// @Param reassign_to query string false "Department receiving the employees when policy is reassign"
 // @NoContent 204
 // @Router /departments/{id] [delete]
 func (h *Handler) DeleteDepartmentByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Department ID is required", http.StatusBadRequest)
+		httperror.BadRequest(w, r, "Department ID is required")
 		return
 	}
 
-	err := h.service.DeleteDepartmentByID(id)
+	policy, err := department.ParseDeletePolicy(r.URL.Query().Get("policy"))
 	if err != nil {
+		httperror.BadRequest(w, r, err.Error())
+		return
+	}
+	opts := department.DeleteOptions{
+		Policy:     policy,
+		ReassignTo: r.URL.Query().Get("reassign_to"),
+	}
+
+	err = h.service.DeleteDepartmentByID(r.Context(), id, etag.IfMatch(r), opts)
+	if err != nil {
+		httperror.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// @Summary Get Child Departments
+// @Description get the departments directly under a department
+// @Tags departments
+// @Produce  json
+// @Param id path string true "ID"
+// @Success 200 {array} Department
+// @Router /departments/{id}/children [get]
+func (h *Handler) GetChildren(w http.ResponseWriter, r *http.Request) {
+	children, err := h.service.GetChildren(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(children)
+	if err != nil {
+		return
+	}
+}
+
+// @Summary Get Department Subtree
+// @Description get a department with every department below it nested in children
+// @Tags departments
+// @Produce  json
+// @Param id path string true "ID"
+// @Success 200 {object} department.Node
+// @Router /departments/{id}/subtree [get]
+func (h *Handler) GetSubtree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetSubtree(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(tree)
+	if err != nil {
+		return
+	}
+}
+
+// HeadRequest is the body of a PUT to the head of a department.
+type HeadRequest struct {
+	// HeadID is the employee to put at the head, empty to leave the
+	// department without one.
+	HeadID string `json:"head_id"`
+}
+
+// @Summary Set Department Head
+// @Description set the employee running a department, one of its own staff, or clear it with an empty head_id
+// @Tags department
+// @Accept  json
+// @Produce  json
+// @Param id path string true "ID"
+// @Param If-Match header string false "ETag of the version being replaced"
+// @Param head body HeadRequest true "Head employee"
+// @Success 200 {object} Department
+// @Router /departments/{id}/head [put]
+func (h *Handler) SetHead(w http.ResponseWriter, r *http.Request) {
+	var body HeadRequest
+	if err := request.DecodeJSON(r, &body); err != nil {
+		httperror.BadRequest(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+
+	updated, err := h.service.SetHead(r.Context(), mux.Vars(r)["id"], etag.IfMatch(r), body.HeadID)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag.Format(updated.Version))
+	err = json.NewEncoder(w).Encode(updated)
+	if err != nil {
+		return
+	}
+}
@@ -1,12 +1,25 @@
 package department
 
+import "strings"
+
 type DepartmentRoutes struct {
 	Base         string
 	ByID         string
 	ByDepartment string
+	Children     string
+	Subtree      string
+	Head         string
 }
 
 var Departments = DepartmentRoutes{
-	Base: "/departments",
-	ByID: "/departments/{id}",
+	Base:     "/departments",
+	ByID:     "/departments/{id}",
+	Children: "/departments/{id}/children",
+	Subtree:  "/departments/{id}/subtree",
+	Head:     "/departments/{id}/head",
+}
+
+// Location returns the path of the department identified by id.
+func (r DepartmentRoutes) Location(id string) string {
+	return strings.Replace(r.ByID, "{id}", id, 1)
 }
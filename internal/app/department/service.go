@@ -1,36 +1,164 @@
 package department
 
 import (
+	"context"
+	"errors"
+	"github.com/oklog/ulid/v2"
+	"slices"
+	"template-golang/internal/app/patch"
 	model "template-golang/internal/domain/department"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/logging"
 	repository "template-golang/internal/repository/department"
+	repositoryEmployee "template-golang/internal/repository/employee"
 )
 
 type Service struct {
 	repo repository.Repository
+	// employees resolves the records embedded by Expand.
+	employees repositoryEmployee.Repository
 }
 
-func NewService(repo repository.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo repository.Repository, employees repositoryEmployee.Repository) *Service {
+	return &Service{repo: repo, employees: employees}
 }
 
-func (s *Service) GetAllDepartments() ([]model.Department, error) {
-	return s.repo.GetAllDepartments()
+func (s *Service) GetAllDepartments(ctx context.Context) ([]model.Department, error) {
+	return s.repo.GetAllDepartments(ctx)
 }
 
-func (s *Service) CreateDepartment(e model.Department) {
-	err := s.repo.CreateDepartment(e)
+func (s *Service) ListDepartments(ctx context.Context, q model.ListQuery) (listing.Page[model.Department], error) {
+	return s.repo.ListDepartments(ctx, q)
+}
+
+// CreateDepartment validates and stores a new department, generating a time-sortable ID
+// when none is given.
+func (s *Service) CreateDepartment(ctx context.Context, e model.Department) (*model.Department, error) {
+	e.Normalize()
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	if e.ID == "" {
+		e.ID = ulid.Make().String()
+	}
+	created, err := s.repo.CreateDepartment(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("department created", "id", created.ID)
+	return created, nil
+}
+
+func (s *Service) GetDepartmentByID(ctx context.Context, id string) (*model.Department, error) {
+	return s.repo.GetDepartmentByID(ctx, id)
+}
+
+func (s *Service) UpdateDepartmentByID(ctx context.Context, id string, cond version.Condition, update model.Department) (*model.Department, error) {
+	update.Normalize()
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdateDepartmentByID(ctx, id, cond, update)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("department updated", "id", id, "version", updated.Version)
+	return updated, nil
+}
+
+// PatchDepartmentByID applies p to the stored department and validates the result
+// before it is saved.
+func (s *Service) PatchDepartmentByID(ctx context.Context, id string, cond version.Condition, p patch.Patch) (*model.Department, error) {
+	patched, err := s.repo.PatchDepartmentByID(ctx, id, cond, func(current model.Department) (model.Department, error) {
+		var patched model.Department
+		if err := p.Apply(current, &patched); err != nil {
+			return patched, err
+		}
+		if patched.ID != current.ID {
+			return patched, errs.Invalid(errs.FieldError{Field: "id", Message: "cannot be changed"})
+		}
+		patched.Normalize()
+		return patched, patched.Validate()
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("department patched", "id", id, "version", patched.Version)
+	return patched, nil
+}
+
+func (s *Service) DeleteDepartmentByID(ctx context.Context, id string, cond version.Condition, opts model.DeleteOptions) error {
+	if err := s.repo.DeleteDepartmentByID(ctx, id, cond, opts); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("department deleted", "id", id, "policy", string(opts.Policy))
+	return nil
+}
+
+// GetChildren returns the departments directly under id.
+func (s *Service) GetChildren(ctx context.Context, id string) ([]model.Department, error) {
+	return s.repo.GetChildren(ctx, id, false)
+}
+
+// GetSubtree returns the department id with every department below it.
+func (s *Service) GetSubtree(ctx context.Context, id string) (*model.Node, error) {
+	root, err := s.repo.GetDepartmentByID(ctx, id)
 	if err != nil {
-		return
+		return nil, err
 	}
+	descendants, err := s.repo.GetChildren(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	tree := model.Tree(*root, descendants)
+	return &tree, nil
 }
-func (s *Service) GetDepartmentByID(id string) (*model.Department, error) {
-	return s.repo.GetDepartmentByID(id)
+
+// SetHead makes headID the head of the department, or leaves it without one
+// when headID is empty.
+func (s *Service) SetHead(ctx context.Context, id string, cond version.Condition, headID string) (*model.Department, error) {
+	updated, err := s.repo.PatchDepartmentByID(ctx, id, cond, func(current model.Department) (model.Department, error) {
+		current.HeadID = headID
+		current.Normalize()
+		return current, current.Validate()
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("department head set", "id", id, "head_id", updated.HeadID, "version", updated.Version)
+	return updated, nil
 }
 
-func (s *Service) UpdateDepartmentByID(id string, update model.Department) error {
-	return s.repo.UpdateDepartmentByID(id, update)
+// Expand embeds in d the related records named by expand, taken from
+// model.Expansions. A head deleted since d was read is left out.
+func (s *Service) Expand(ctx context.Context, d model.Department, expand []string) (model.Expanded, error) {
+	expanded := model.Expanded{Department: d}
+	if slices.Contains(expand, model.ExpandHead) && d.HeadID != "" {
+		head, err := s.employees.GetEmployeeByID(ctx, d.HeadID)
+		if err != nil && !errors.Is(err, errs.ErrNotFound) {
+			return expanded, err
+		}
+		expanded.Head = head
+	}
+	return expanded, nil
 }
 
-func (s *Service) DeleteDepartmentByID(id string) error {
-	return s.repo.DeleteDepartmentByID(id)
+// ExpandPage embeds the related records named by expand in every department
+// of page.
+func (s *Service) ExpandPage(ctx context.Context, page listing.Page[model.Department], expand []string) (listing.Page[model.Expanded], error) {
+	expanded := listing.Page[model.Expanded]{
+		Items:      make([]model.Expanded, 0, len(page.Items)),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+	for _, d := range page.Items {
+		item, err := s.Expand(ctx, d, expand)
+		if err != nil {
+			return expanded, err
+		}
+		expanded.Items = append(expanded.Items, item)
+	}
+	return expanded, nil
 }
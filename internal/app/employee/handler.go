@@ -2,9 +2,16 @@ package employee
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
 	"net/http"
+	"strconv"
+	"template-golang/internal/app/etag"
+	"template-golang/internal/app/httperror"
+	"template-golang/internal/app/patch"
+	"template-golang/internal/app/request"
 	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
 )
 
 type Handler struct {
@@ -20,38 +27,70 @@ func NewHandler(service *Service) *Handler {
 // @Tags employee
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} Employee
+// @Success 201 {object} Employee
+// @Failure 409 {string} string "Employee already exists"
 // @Router /employees [post]
 func (h *Handler) CreateEmployee(w http.ResponseWriter, r *http.Request) {
 	var emp employee.Employee
-	if err := json.NewDecoder(r.Body).Decode(&emp); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := request.DecodeJSON(r, &emp); err != nil {
+		httperror.BadRequest(w, r, "Invalid request body: "+err.Error())
 		return
 	}
 
-	h.service.CreateEmployee(emp)
+	created, err := h.service.CreateEmployee(r.Context(), emp)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", Employees.Location(created.ID))
+	w.Header().Set("ETag", etag.Format(created.Version))
 	w.WriteHeader(http.StatusCreated)
-	err := json.NewEncoder(w).Encode(emp)
+	err = json.NewEncoder(w).Encode(created)
 	if err != nil {
 		return
 	}
 }
 
 // @Summary Get Employees
-// @Description get list of employees
+// @Description get a page of employees
 // @Tags employees
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} Employee
+// @Param limit query int false "Page size"
+// @Param after query string false "Cursor returned as next_cursor by the previous page"
+// @Param sort query string false "Sort order: id, name or position"
+// @Param position query string false "Only employees holding this position"
+// @Param name_prefix query string false "Only employees whose name starts with this prefix"
+// @Param status query string false "Only employees in this status"
+// @Success 200 {object} listing.Page[Employee]
 // @Router /employees [get]
 func (h *Handler) GetAllEmployees(w http.ResponseWriter, r *http.Request) {
-	employees, err := h.service.GetAllEmployees()
+	q, err := request.ListQuery(r, employee.Sorts)
 	if err != nil {
-		http.Error(w, "Failed to retrieve employees", http.StatusInternalServerError)
+		httperror.BadRequest(w, r, err.Error())
 		return
 	}
-	err = json.NewEncoder(w).Encode(employees)
+	status, err := employee.ParseStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		httperror.BadRequest(w, r, err.Error())
+		return
+	}
+
+	page, err := h.service.ListEmployees(r.Context(), employee.ListQuery{
+		Query:      q,
+		Position:   r.URL.Query().Get("position"),
+		NamePrefix: r.URL.Query().Get("name_prefix"),
+		Status:     status,
+	})
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(page)
 	if err != nil {
 		return
 	}
@@ -63,19 +102,26 @@ func (h *Handler) GetAllEmployees(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "ID"
+// @Param If-None-Match header string false "ETag of the cached version"
 // @Success 200 {array} Employee
 // @Router /employees/{id} [get]
 func (h *Handler) GetEmployeeById(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Employee ID is required", http.StatusBadRequest)
+		httperror.BadRequest(w, r, "Employee ID is required")
 		return
 	}
 
-	employee, err := h.service.GetEmployeeByID(id)
+	employee, err := h.service.GetEmployeeByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.Format(employee.Version))
+	if etag.NoneMatch(r, employee.Version) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -92,31 +138,128 @@ func (h *Handler) GetEmployeeById(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "ID"
+// @Param If-Match header string false "ETag of the version being replaced"
 // @Created 201
 // @Router /employees/{id] [put]
 func (h *Handler) UpdateEmployeeByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Employee ID is required", http.StatusBadRequest)
+		httperror.BadRequest(w, r, "Employee ID is required")
 		return
 	}
 
 	var emp employee.Employee
-	if err := json.NewDecoder(r.Body).Decode(&emp); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := request.DecodeJSON(r, &emp); err != nil {
+		httperror.BadRequest(w, r, "Invalid request body: "+err.Error())
 		return
 	}
 
 	// Set the Employee ID from the URL parameter to ensure consistency
 	emp.ID = id
-	if err := h.service.UpdateEmployeeByID(id, emp); err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+	updated, err := h.service.UpdateEmployeeByID(r.Context(), id, etag.IfMatch(r), emp)
+	if err != nil {
+		httperror.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag.Format(updated.Version))
 	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(emp)
+	err = json.NewEncoder(w).Encode(updated)
+	if err != nil {
+		return
+	}
+}
+
+// @Summary Patch Employee
+// @Description partially update employee with a JSON Merge Patch or a JSON Patch
+// @Tags employee
+// @Accept  application/merge-patch+json
+// @Accept  application/json-patch+json
+// @Produce  json
+// @Param id path string true "ID"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Success 200 {object} Employee
+// @Router /employees/{id} [patch]
+func (h *Handler) PatchEmployeeByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		httperror.BadRequest(w, r, "Employee ID is required")
+		return
+	}
+
+	p, err := request.Patch(r)
+	if errors.Is(err, patch.ErrUnsupportedMediaType) {
+		w.Header().Set("Accept-Patch", patch.Accepted)
+		httperror.Status(w, r, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	if err != nil {
+		httperror.BadRequest(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+
+	patched, err := h.service.PatchEmployeeByID(r.Context(), id, etag.IfMatch(r), p)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag.Format(patched.Version))
+	err = json.NewEncoder(w).Encode(patched)
+	if err != nil {
+		return
+	}
+}
+
+// TransitionRequest gives the effective date, today by default, and the
+// reason of a status transition.
+type TransitionRequest struct {
+	EffectiveDate string `json:"effective_date"`
+	Reason        string `json:"reason"`
+}
+
+// @Summary Transition Employee
+// @Description move an employee through the lifecycle: onboard, activate, leave, terminate or rehire
+// @Tags employee
+// @Accept  json
+// @Produce  json
+// @Param id path string true "ID"
+// @Param transition path string true "onboard, activate, leave, terminate or rehire"
+// @Param If-Match header string false "ETag of the version being transitioned"
+// @Param transition body TransitionRequest false "Effective date and reason"
+// @Success 200 {object} Employee
+// @Failure 409 {string} string "Transition not allowed from the current status"
+// @Router /employees/{id}/{transition} [post]
+func (h *Handler) Transition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	t, ok := employee.TransitionNamed(vars["transition"])
+	if !ok {
+		httperror.Status(w, r, http.StatusNotFound, "Unknown transition "+vars["transition"])
+		return
+	}
+
+	var req TransitionRequest
+	if r.ContentLength != 0 {
+		if err := request.DecodeJSON(r, &req); err != nil {
+			httperror.BadRequest(w, r, "Invalid request body: "+err.Error())
+			return
+		}
+	}
+
+	moved, err := h.service.Transition(r.Context(), id, etag.IfMatch(r), t, req.EffectiveDate, req.Reason)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag.Format(moved.Version))
+	err = json.NewEncoder(w).Encode(moved)
 	if err != nil {
 		return
 	}
@@ -127,18 +270,20 @@ func (h *Handler) UpdateEmployeeByID(w http.ResponseWriter, r *http.Request) {
 // @Tags employee
 // @Accept  json
 // @Param id path string true "ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @NoContent 204
 // @Router /employees/{id] [delete]
 func (h *Handler) DeleteEmployeeByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Employee ID is required", http.StatusBadRequest)
+		httperror.BadRequest(w, r, "Employee ID is required")
 		return
 	}
 
-	err := h.service.DeleteEmployeeByID(id)
+	err := h.service.DeleteEmployeeByID(r.Context(), id, etag.IfMatch(r))
 	if err != nil {
+		httperror.Write(w, r, err)
 		return
 	}
 
@@ -151,28 +296,34 @@ func (h *Handler) DeleteEmployeeByID(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param dept_id path string true "Department ID"
+// @Param recursive query bool false "Include the employees of every department below it"
 // @Success 200 {array} Employee
 // @Router /departments/{dept_id}/employees [get]
 func (h *Handler) GetAllEmployeesByDepartmentID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	deptID, ok := vars["deptId"]
 	if !ok {
-		http.Error(w, "Department ID is required", http.StatusBadRequest)
+		httperror.BadRequest(w, r, "Department ID is required")
 		return
 	}
 
-	employees, err := h.service.GetAllEmployeesByDepartmentID(deptID)
+	var recursive bool
+	if s := r.URL.Query().Get("recursive"); s != "" {
+		var err error
+		if recursive, err = strconv.ParseBool(s); err != nil {
+			httperror.BadRequest(w, r, "recursive must be true or false")
+			return
+		}
+	}
+
+	employees, err := h.service.GetAllEmployeesByDepartmentID(r.Context(), deptID, recursive)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		httperror.Write(w, r, err)
 		return
 	}
 
 	if len(employees) == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		_, err := w.Write([]byte("No employees found in this department"))
-		if err != nil {
-			return
-		}
+		httperror.Write(w, r, errs.NotFound("No employees found in department %s", deptID))
 		return
 	}
 
@@ -182,3 +333,77 @@ func (h *Handler) GetAllEmployeesByDepartmentID(w http.ResponseWriter, r *http.R
 		return
 	}
 }
+
+// @Summary Get Reports
+// @Description get the employees reporting to an employee, directly or with depth=all at any level
+// @Tags employees
+// @Produce  json
+// @Param id path string true "ID"
+// @Param depth query string false "1 for direct reports (default) or all"
+// @Success 200 {array} Employee
+// @Router /employees/{id}/reports [get]
+func (h *Handler) GetReports(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var transitive bool
+	switch depth := r.URL.Query().Get("depth"); depth {
+	case "", "1":
+	case "all":
+		transitive = true
+	default:
+		httperror.BadRequest(w, r, "depth must be 1 or all")
+		return
+	}
+
+	reports, err := h.service.GetReports(r.Context(), id, transitive)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(reports)
+	if err != nil {
+		return
+	}
+}
+
+// @Summary Get Management Chain
+// @Description get the managers above an employee, from the direct manager to the top
+// @Tags employees
+// @Produce  json
+// @Param id path string true "ID"
+// @Success 200 {array} Employee
+// @Router /employees/{id}/chain [get]
+func (h *Handler) GetManagementChain(w http.ResponseWriter, r *http.Request) {
+	chain, err := h.service.GetManagementChain(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(chain)
+	if err != nil {
+		return
+	}
+}
+
+// @Summary Get Org Chart
+// @Description get every employee arranged in the trees of their reporting lines
+// @Tags employees
+// @Produce  json
+// @Success 200 {array} employee.OrgNode
+// @Router /orgchart [get]
+func (h *Handler) GetOrgChart(w http.ResponseWriter, r *http.Request) {
+	chart, err := h.service.GetOrgChart(r.Context())
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(chart)
+	if err != nil {
+		return
+	}
+}
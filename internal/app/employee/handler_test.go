@@ -0,0 +1,330 @@
+package employee_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"template-golang/internal/app/employee"
+	"template-golang/internal/app/etag"
+	"template-golang/internal/app/httperror"
+	"template-golang/internal/app/patch"
+	"template-golang/internal/domain/department"
+	domainEmployee "template-golang/internal/domain/employee"
+	repositoryDept "template-golang/internal/repository/department"
+	repositoryEmployee "template-golang/internal/repository/employee"
+	"template-golang/internal/repository/memory"
+	"testing"
+)
+
+// newRouter serves the employee endpoints over a memory store holding the
+// department eng and the employee e1.
+func newRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	store := memory.NewStore()
+	ctx := context.Background()
+	if _, err := repositoryDept.NewMemoryRepository(store).CreateDepartment(ctx, department.Department{ID: "eng", Name: "Engineering"}); err != nil {
+		t.Fatal(err)
+	}
+	repo := repositoryEmployee.NewMemoryRepository(store)
+	if _, err := repo.CreateEmployee(ctx, domainEmployee.Employee{ID: "e1", Name: "Ada", Position: "Engineer", DepartmentId: "eng"}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := employee.NewHandler(employee.NewService(repo))
+	r := mux.NewRouter()
+	r.HandleFunc(employee.Employees.Base, handler.CreateEmployee).Methods("POST")
+	r.HandleFunc(employee.Employees.ByID, handler.GetEmployeeById).Methods("GET")
+	r.HandleFunc(employee.Employees.ByID, handler.UpdateEmployeeByID).Methods("PUT")
+	r.HandleFunc(employee.Employees.ByID, handler.PatchEmployeeByID).Methods("PATCH")
+	r.HandleFunc(employee.Employees.ByID, handler.DeleteEmployeeByID).Methods("DELETE")
+	r.HandleFunc(employee.Employees.Transition, handler.Transition).Methods("POST")
+	return r
+}
+
+func serve(r http.Handler, method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeEmployee(t *testing.T, w *httptest.ResponseRecorder) domainEmployee.Employee {
+	t.Helper()
+	var e domainEmployee.Employee
+	if err := json.NewDecoder(w.Body).Decode(&e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) httperror.Problem {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != httperror.ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, httperror.ContentType)
+	}
+	var p httperror.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPatchRejectsUnsupportedMediaType(t *testing.T) {
+	r := newRouter(t)
+	w := serve(r, http.MethodPatch, "/employees/e1", map[string]string{"Content-Type": "application/json"}, `{"name":"Grace"}`)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415", w.Code)
+	}
+	if got := w.Header().Get("Accept-Patch"); got != patch.Accepted {
+		t.Errorf("Accept-Patch = %q, want %q", got, patch.Accepted)
+	}
+	decodeProblem(t, w)
+}
+
+func TestPatchMediaTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantName    string
+	}{
+		{"merge patch", patch.MergePatch, `{"name":"Grace Hopper"}`, "Grace Hopper"},
+		{"JSON patch", patch.JSONPatch, `[{"op":"test","path":"/name","value":"Ada"},{"op":"replace","path":"/name","value":"Grace Hopper"}]`, "Grace Hopper"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter(t)
+			w := serve(r, http.MethodPatch, "/employees/e1", map[string]string{"Content-Type": tt.contentType}, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			e := decodeEmployee(t, w)
+			if e.Name != tt.wantName || e.Position != "Engineer" || e.Version != 2 {
+				t.Errorf("patched = %+v, want name %q, the position kept and version 2", e, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantField   string
+	}{
+		{"malformed patch", patch.MergePatch, `{"name":`, http.StatusBadRequest, ""},
+		{"failed test operation", patch.JSONPatch, `[{"op":"test","path":"/name","value":"Grace"}]`, http.StatusConflict, ""},
+		{"invalid result", patch.MergePatch, `{"name":"","position":"Engineer"}`, http.StatusUnprocessableEntity, "name"},
+		{"changed ID", patch.MergePatch, `{"id":"e2"}`, http.StatusUnprocessableEntity, "id"},
+		{"missing employee", patch.MergePatch, `{"name":"Grace"}`, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/employees/e1"
+			if tt.wantStatus == http.StatusNotFound {
+				path = "/employees/missing"
+			}
+			w := serve(newRouter(t), http.MethodPatch, path, map[string]string{"Content-Type": tt.contentType}, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			p := decodeProblem(t, w)
+			if p.Status != tt.wantStatus || p.Instance != path {
+				t.Errorf("problem = %+v", p)
+			}
+			if tt.wantField != "" && (len(p.Errors) != 1 || p.Errors[0].Field != tt.wantField) {
+				t.Errorf("field errors = %+v, want one about %s", p.Errors, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestGetSetsETagAndHonoursIfNoneMatch(t *testing.T) {
+	r := newRouter(t)
+	w := serve(r, http.MethodGet, "/employees/e1", nil, "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("status = %d, ETag %q; want 200 with \"1\"", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = serve(r, http.MethodGet, "/employees/e1", map[string]string{"If-None-Match": `"1"`}, "")
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != `"1"` {
+		t.Errorf("status = %d, ETag %q, body %q; want an empty 304", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+
+	w = serve(r, http.MethodGet, "/employees/e1", map[string]string{"If-None-Match": `"2"`}, "")
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d for a stale If-None-Match, want 200", w.Code)
+	}
+}
+
+func TestWritesCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+	}{
+		{"PUT", http.MethodPut, "application/json", `{"name":"Grace","position":"Engineer","department:id":"eng"}`},
+		{"PATCH", http.MethodPatch, patch.MergePatch, `{"name":"Grace"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter(t)
+			w := serve(r, tt.method, "/employees/e1", map[string]string{"Content-Type": tt.contentType, "If-Match": `"2"`}, tt.body)
+			if w.Code != http.StatusPreconditionFailed {
+				t.Fatalf("status = %d for a stale If-Match, want 412; body %s", w.Code, w.Body)
+			}
+			if p := decodeProblem(t, w); p.Type != httperror.TypePrecondition {
+				t.Errorf("problem type = %q, want %q", p.Type, httperror.TypePrecondition)
+			}
+
+			w = serve(r, tt.method, "/employees/e1", map[string]string{"Content-Type": tt.contentType, "If-Match": `"1"`}, tt.body)
+			if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+				t.Fatalf("status = %d, ETag %q; want 200 with \"2\"", w.Code, w.Header().Get("ETag"))
+			}
+			if e := decodeEmployee(t, w); e.Name != "Grace" || e.Version != 2 {
+				t.Errorf("written = %+v", e)
+			}
+
+			// The tag of the replaced version no longer matches
+			w = serve(r, tt.method, "/employees/e1", map[string]string{"Content-Type": tt.contentType, "If-Match": `"1"`}, tt.body)
+			if w.Code != http.StatusPreconditionFailed {
+				t.Errorf("status = %d for the replaced version, want 412", w.Code)
+			}
+		})
+	}
+}
+
+func TestDeleteChecksIfMatch(t *testing.T) {
+	r := newRouter(t)
+	if w := serve(r, http.MethodDelete, "/employees/e1", map[string]string{"If-Match": `W/"1"`}, ""); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d for a weak If-Match, want 412", w.Code)
+	}
+	if w := serve(r, http.MethodDelete, "/employees/e1", map[string]string{"If-Match": `"1"`}, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/employees/e1", nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("status = %d after the delete, want 404", w.Code)
+	}
+}
+
+func TestWritesRejectUnknownFields(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/employees", `{"name":"Grace","position":"Admiral","department:id":"eng","salary":100}`},
+		{http.MethodPut, "/employees/e1", `{"name":"Grace","position":"Admiral","department:id":"eng","departmentId":"eng"}`},
+		{http.MethodPost, "/employees", `{"name":"Grace","position":"Admiral","department:id":"eng"} {}`},
+	}
+	for _, tt := range tests {
+		w := serve(newRouter(t), tt.method, tt.path, map[string]string{"Content-Type": "application/json"}, tt.body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: status = %d, want 400", tt.method, tt.path, tt.body, w.Code)
+			continue
+		}
+		if p := decodeProblem(t, w); p.Type != httperror.TypeBadRequest {
+			t.Errorf("problem type = %q, want %q", p.Type, httperror.TypeBadRequest)
+		}
+	}
+}
+
+func TestCreateReportsEveryInvalidField(t *testing.T) {
+	w := serve(newRouter(t), http.MethodPost, "/employees", map[string]string{"Content-Type": "application/json"},
+		`{"id":"e 2","name":"  ","position":"Admiral","department:id":"eng!"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422; body %s", w.Code, w.Body)
+	}
+	p := decodeProblem(t, w)
+	var fields []string
+	for _, e := range p.Errors {
+		fields = append(fields, e.Field)
+	}
+	if strings.Join(fields, ",") != "id,name,department:id" {
+		t.Errorf("field errors = %+v, want id, name and department:id", p.Errors)
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	r := newRouter(t)
+	steps := []struct {
+		path       string
+		body       string
+		wantStatus int
+		want       domainEmployee.Status
+	}{
+		{"/employees/e1/leave", `{"effective_date":"2024-03-01","reason":"parental leave"}`, http.StatusOK, domainEmployee.OnLeave},
+		{"/employees/e1/leave", `{"effective_date":"2024-03-02"}`, http.StatusConflict, ""},
+		{"/employees/e1/rehire", `{"effective_date":"2024-03-02"}`, http.StatusConflict, ""},
+		{"/employees/e1/activate", `{"effective_date":"2024-02-01"}`, http.StatusUnprocessableEntity, ""},
+		{"/employees/e1/activate", `{"effective_date":"2024-06-01"}`, http.StatusOK, domainEmployee.Active},
+		{"/employees/e1/terminate", `{"effective_date":"2024-09-30","reason":"resigned"}`, http.StatusOK, domainEmployee.Terminated},
+		{"/employees/e1/rehire", `{"effective_date":"2025-01-06"}`, http.StatusOK, domainEmployee.Rehired},
+		{"/employees/e1/promote", `{"effective_date":"2025-02-01"}`, http.StatusNotFound, ""},
+		{"/employees/missing/activate", `{"effective_date":"2025-02-01"}`, http.StatusNotFound, ""},
+		{"/employees/e1/activate", `{"effective_date":"2025-02-01","when":"now"}`, http.StatusBadRequest, ""},
+	}
+	for _, step := range steps {
+		w := serve(r, http.MethodPost, step.path, map[string]string{"Content-Type": "application/json"}, step.body)
+		if w.Code != step.wantStatus {
+			t.Fatalf("POST %s %s: status = %d, want %d; body %s", step.path, step.body, w.Code, step.wantStatus, w.Body)
+		}
+		if step.wantStatus != http.StatusOK {
+			decodeProblem(t, w)
+			continue
+		}
+		if e := decodeEmployee(t, w); e.Status != step.want || w.Header().Get("ETag") != etag.Format(e.Version) {
+			t.Errorf("POST %s: status %s, ETag %q; want %s at version %d", step.path, e.Status, w.Header().Get("ETag"), step.want, e.Version)
+		}
+	}
+
+	w := serve(r, http.MethodGet, "/employees/e1", nil, "")
+	if e := decodeEmployee(t, w); e.HireDate != "2025-01-06" || e.TerminationDate != "" || e.StatusDate != "2025-01-06" {
+		t.Errorf("rehired = %+v, want hired again on 2025-01-06", e)
+	}
+}
+
+func TestTransitionDefaultsToToday(t *testing.T) {
+	r := newRouter(t)
+	w := serve(r, http.MethodPost, "/employees/e1/leave", nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if e := decodeEmployee(t, w); e.Status != domainEmployee.OnLeave || e.StatusDate != domainEmployee.Today() || e.StatusReason != "" {
+		t.Errorf("moved = %+v, want on leave from today", e)
+	}
+}
+
+func TestUpdatesLeaveTheStatusAlone(t *testing.T) {
+	r := newRouter(t)
+	w := serve(r, http.MethodPatch, "/employees/e1", map[string]string{"Content-Type": patch.MergePatch}, `{"status":"terminated"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("PATCH status: status = %d, want 409", w.Code)
+	}
+	if p := decodeProblem(t, w); p.Detail != domainEmployee.ErrStatusChanged.Message {
+		t.Errorf("detail = %q, want %q", p.Detail, domainEmployee.ErrStatusChanged.Message)
+	}
+	w = serve(r, http.MethodPatch, "/employees/e1", map[string]string{"Content-Type": patch.JSONPatch}, `[{"op":"add","path":"/hire_date","value":"2020-01-01"}]`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("PATCH hire_date: status = %d, want 409", w.Code)
+	}
+
+	// A replacement keeps the status and the dates set by transitions
+	w = serve(r, http.MethodPut, "/employees/e1", map[string]string{"Content-Type": "application/json"},
+		`{"name":"Ada","position":"Chief","department:id":"eng","status":"terminated","hire_date":"2020-01-01"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: status = %d, body %s", w.Code, w.Body)
+	}
+	if e := decodeEmployee(t, w); e.Position != "Chief" || e.Status != domainEmployee.Active || e.HireDate != "" {
+		t.Errorf("replaced = %+v, want the position changed and the status kept", e)
+	}
+}
@@ -1,13 +1,28 @@
 package employee
 
+import "strings"
+
 type EmployeeRoutes struct {
 	Base         string
 	ByID         string
 	ByDepartment string
+	Reports      string
+	Chain        string
+	OrgChart     string
+	Transition   string
 }
 
 var Employees = EmployeeRoutes{
 	Base:         "/employees",
 	ByID:         "/employees/{id}",
 	ByDepartment: "/employees/department/{deptId}",
+	Reports:      "/employees/{id}/reports",
+	Chain:        "/employees/{id}/chain",
+	OrgChart:     "/orgchart",
+	Transition:   "/employees/{id}/{transition}",
+}
+
+// Location returns the path of the employee identified by id.
+func (r EmployeeRoutes) Location(id string) string {
+	return strings.Replace(r.ByID, "{id}", id, 1)
 }
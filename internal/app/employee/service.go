@@ -1,7 +1,15 @@
 package employee
 
 import (
+	"context"
+	"github.com/oklog/ulid/v2"
+	"slices"
+	"template-golang/internal/app/patch"
 	model "template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/logging"
 	repository "template-golang/internal/repository/employee"
 )
 
@@ -13,28 +21,135 @@ func NewService(repo repository.Repository) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) GetAllEmployees() ([]model.Employee, error) {
-	return s.repo.GetAllEmployees()
+func (s *Service) GetAllEmployees(ctx context.Context) ([]model.Employee, error) {
+	return s.repo.GetAllEmployees(ctx)
 }
 
-func (s *Service) CreateEmployee(e model.Employee) {
-	err := s.repo.CreateEmployee(e)
+func (s *Service) ListEmployees(ctx context.Context, q model.ListQuery) (listing.Page[model.Employee], error) {
+	return s.repo.ListEmployees(ctx, q)
+}
+
+// CreateEmployee validates and stores a new employee, generating a time-sortable ID
+// when none is given. New employees start in one of the initial statuses and
+// cannot have been terminated.
+func (s *Service) CreateEmployee(ctx context.Context, e model.Employee) (*model.Employee, error) {
+	e.Normalize()
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	if e.Status != "" && !slices.Contains(model.InitialStatuses, e.Status) {
+		return nil, errs.Invalid(errs.FieldError{Field: "status", Message: "must be candidate, onboarding or active for a new employee"})
+	}
+	if e.TerminationDate != "" {
+		return nil, errs.Invalid(errs.FieldError{Field: "termination_date", Message: "is set by the terminate transition"})
+	}
+	if e.ID == "" {
+		e.ID = ulid.Make().String()
+	}
+	created, err := s.repo.CreateEmployee(ctx, e)
 	if err != nil {
-		return
+		return nil, err
 	}
+	logging.FromContext(ctx).Info("employee created", "id", created.ID)
+	return created, nil
 }
-func (s *Service) GetEmployeeByID(id string) (*model.Employee, error) {
-	return s.repo.GetEmployeeByID(id)
+
+func (s *Service) GetEmployeeByID(ctx context.Context, id string) (*model.Employee, error) {
+	return s.repo.GetEmployeeByID(ctx, id)
 }
 
-func (s *Service) UpdateEmployeeByID(id string, update model.Employee) error {
-	return s.repo.UpdateEmployeeByID(id, update)
+func (s *Service) UpdateEmployeeByID(ctx context.Context, id string, cond version.Condition, update model.Employee) (*model.Employee, error) {
+	update.Normalize()
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdateEmployeeByID(ctx, id, cond, update)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("employee updated", "id", id, "version", updated.Version)
+	return updated, nil
 }
 
-func (s *Service) DeleteEmployeeByID(id string) error {
-	return s.repo.DeleteEmployeeByID(id)
+// PatchEmployeeByID applies p to the stored employee and validates the result
+// before it is saved. The status and the employment dates cannot be patched,
+// only transitioned.
+func (s *Service) PatchEmployeeByID(ctx context.Context, id string, cond version.Condition, p patch.Patch) (*model.Employee, error) {
+	patched, err := s.repo.PatchEmployeeByID(ctx, id, cond, func(current model.Employee) (model.Employee, error) {
+		var patched model.Employee
+		if err := p.Apply(current, &patched); err != nil {
+			return patched, err
+		}
+		if patched.ID != current.ID {
+			return patched, errs.Invalid(errs.FieldError{Field: "id", Message: "cannot be changed"})
+		}
+		patched.Normalize()
+		if patched.Status != current.Status || patched.StatusDate != current.StatusDate || patched.StatusReason != current.StatusReason ||
+			patched.HireDate != current.HireDate || patched.TerminationDate != current.TerminationDate {
+			return patched, model.ErrStatusChanged
+		}
+		return patched, patched.Validate()
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("employee patched", "id", id, "version", patched.Version)
+	return patched, nil
 }
 
-func (s *Service) GetAllEmployeesByDepartmentID(deptID string) ([]model.Employee, error) {
-	return s.repo.GetAllEmployeesByDepartmentID(deptID)
+// Transition moves the employee through t, effective on date, which
+// defaults to today. Transitions that do not start from the current status
+// are conflicts.
+func (s *Service) Transition(ctx context.Context, id string, cond version.Condition, t model.Transition, date, reason string) (*model.Employee, error) {
+	if date == "" {
+		date = model.Today()
+	}
+	moved, err := s.repo.PatchEmployeeByID(ctx, id, cond, func(e model.Employee) (model.Employee, error) {
+		if err := e.Apply(t, date, reason); err != nil {
+			return e, err
+		}
+		return e, e.Validate()
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("employee status changed", "id", id, "status", moved.Status, "version", moved.Version)
+	return moved, nil
+}
+
+func (s *Service) DeleteEmployeeByID(ctx context.Context, id string, cond version.Condition) error {
+	if err := s.repo.DeleteEmployeeByID(ctx, id, cond); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("employee deleted", "id", id)
+	return nil
+}
+
+// GetAllEmployeesByDepartmentID returns the employees of the department, and
+// with recursive those of every department below it as well.
+func (s *Service) GetAllEmployeesByDepartmentID(ctx context.Context, deptID string, recursive bool) ([]model.Employee, error) {
+	if recursive {
+		return s.repo.GetAllEmployeesByDepartmentTree(ctx, deptID)
+	}
+	return s.repo.GetAllEmployeesByDepartmentID(ctx, deptID)
+}
+
+// GetReports returns the employees reporting directly to id, or every
+// employee below id when transitive is set.
+func (s *Service) GetReports(ctx context.Context, id string, transitive bool) ([]model.Employee, error) {
+	return s.repo.GetReports(ctx, id, transitive)
+}
+
+// GetManagementChain returns the managers above id, nearest first.
+func (s *Service) GetManagementChain(ctx context.Context, id string) ([]model.Employee, error) {
+	return s.repo.GetManagementChain(ctx, id)
+}
+
+// GetOrgChart returns the organization as the trees of its reporting lines.
+func (s *Service) GetOrgChart(ctx context.Context) ([]model.OrgNode, error) {
+	employees, err := s.repo.GetAllEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return model.OrgChart(employees), nil
 }
@@ -0,0 +1,60 @@
+package etag
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"template-golang/internal/domain/version"
+)
+
+// Format returns the strong entity tag of a record version.
+func Format(v int64) string {
+	return `"` + strconv.FormatInt(v, 10) + `"`
+}
+
+// IfMatch converts the If-Match header into a version condition. Weak and
+// malformed tags never match.
+func IfMatch(r *http.Request) version.Condition {
+	tags := tags(r.Header.Values("If-Match"))
+	if len(tags) == 0 || tags[0] == "*" {
+		return version.Any()
+	}
+	var versions []int64
+	for _, tag := range tags {
+		if v, ok := parse(tag); ok {
+			versions = append(versions, v)
+		}
+	}
+	return version.Matching(versions...)
+}
+
+// NoneMatch reports whether the If-None-Match header matches version v, in
+// which case a GET must be answered with 304 Not Modified.
+func NoneMatch(r *http.Request, v int64) bool {
+	for _, tag := range tags(r.Header.Values("If-None-Match")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == Format(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func tags(headers []string) []string {
+	var tags []string
+	for _, header := range headers {
+		for _, tag := range strings.Split(header, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func parse(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	return v, err == nil
+}
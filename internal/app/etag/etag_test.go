@@ -0,0 +1,76 @@
+package etag_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"template-golang/internal/app/etag"
+	"testing"
+)
+
+func request(header string, values ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/employees/e1", nil)
+	for _, v := range values {
+		r.Header.Add(header, v)
+	}
+	return r
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		holds   []int64
+		fails   []int64
+	}{
+		{"absent", nil, []int64{1, 7}, nil},
+		{"any", []string{"*"}, []int64{1, 7}, nil},
+		{"single tag", []string{`"3"`}, []int64{3}, []int64{2, 4}},
+		{"list", []string{`"3", "5"`}, []int64{3, 5}, []int64{4}},
+		{"repeated header", []string{`"3"`, `"5"`}, []int64{3, 5}, []int64{4}},
+		// Weak and malformed tags cannot guard a write
+		{"weak tag", []string{`W/"3"`}, nil, []int64{3}},
+		{"unquoted tag", []string{`3`}, nil, []int64{3}},
+		{"not a version", []string{`"abc"`}, nil, []int64{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := etag.IfMatch(request("If-Match", tt.headers...))
+			for _, v := range tt.holds {
+				if !c.Holds(v) {
+					t.Errorf("If-Match %q does not hold for version %d", tt.headers, v)
+				}
+			}
+			for _, v := range tt.fails {
+				if c.Holds(v) {
+					t.Errorf("If-Match %q holds for version %d", tt.headers, v)
+				}
+			}
+		})
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`"3"`, true},
+		{`"2"`, false},
+		{`"2", "3"`, true},
+		// Weak comparison applies to GET
+		{`W/"3"`, true},
+		{"*", true},
+	}
+	for _, tt := range tests {
+		if got := etag.NoneMatch(request("If-None-Match", tt.header), 3); got != tt.want {
+			t.Errorf("NoneMatch(%q, 3) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := etag.Format(42); got != `"42"` {
+		t.Errorf("Format(42) = %s", got)
+	}
+}
@@ -0,0 +1,147 @@
+package httperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/logging"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Errors   []errs.FieldError `json:"errors,omitempty"`
+	// RequestID lets clients quote the X-Request-ID of a failed request.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Problem types, relative to the API root.
+const (
+	TypeBadRequest   = "/problems/bad-request"
+	TypeNotFound     = "/problems/not-found"
+	TypeConflict     = "/problems/conflict"
+	TypeValidation   = "/problems/validation"
+	TypePrecondition = "/problems/precondition-failed"
+	TypeStorage      = "/problems/storage"
+	TypeTimeout      = "/problems/timeout"
+)
+
+// StatusCode maps a domain error to the HTTP status reported to clients.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, errs.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, errs.ErrPrecondition):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// FromError builds the problem describing err. Storage and unclassified
+// failures are not detailed to the client, and neither is the context error
+// behind a request that ran out of time.
+func FromError(r *http.Request, err error) Problem {
+	status := StatusCode(err)
+	p := Problem{
+		Type:      problemType(err),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  r.URL.Path,
+		RequestID: logging.RequestID(r.Context()),
+	}
+	switch status {
+	case http.StatusInternalServerError:
+	case http.StatusServiceUnavailable:
+		p.Detail = "The request did not complete in time"
+	default:
+		p.Detail = err.Error()
+	}
+	var classified *errs.Error
+	if errors.As(err, &classified) {
+		p.Errors = classified.Fields
+	}
+	return p
+}
+
+// Write replies to the request with the problem describing err, which is
+// logged with the request-scoped logger. Server-side failures are logged as
+// errors since their cause is not shown to the client.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := FromError(r, err)
+	level := slog.LevelDebug
+	if p.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logging.FromContext(r.Context()).LogAttrs(r.Context(), level, "request failed",
+		slog.Int("status", p.Status),
+		slog.String("error", err.Error()),
+	)
+	WriteProblem(w, p)
+}
+
+// BadRequest replies with a 400 problem for requests that cannot be understood.
+func BadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, Problem{
+		Type:      TypeBadRequest,
+		Title:     http.StatusText(http.StatusBadRequest),
+		Status:    http.StatusBadRequest,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: logging.RequestID(r.Context()),
+	})
+}
+
+// Status replies with a problem of the given status for failures that happen
+// before the request reaches the domain.
+func Status(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	WriteProblem(w, Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: logging.RequestID(r.Context()),
+	})
+}
+
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+func problemType(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return TypeTimeout
+	case errors.Is(err, errs.ErrNotFound):
+		return TypeNotFound
+	case errors.Is(err, errs.ErrConflict):
+		return TypeConflict
+	case errors.Is(err, errs.ErrValidation):
+		return TypeValidation
+	case errors.Is(err, errs.ErrPrecondition):
+		return TypePrecondition
+	case errors.Is(err, errs.ErrStorage):
+		return TypeStorage
+	default:
+		return "about:blank"
+	}
+}
@@ -0,0 +1,101 @@
+package httperror_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"template-golang/internal/app/httperror"
+	"template-golang/internal/domain/errs"
+	"testing"
+)
+
+func TestWriteMapsErrorsToProblems(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+		wantDetail string
+	}{
+		{"not found", errs.NotFound("Employee e1 not found"), http.StatusNotFound, httperror.TypeNotFound, "Employee e1 not found"},
+		{"conflict", errs.Conflict("Employee already exists"), http.StatusConflict, httperror.TypeConflict, "Employee already exists"},
+		{"validation", errs.Validation("Department does not exist"), http.StatusUnprocessableEntity, httperror.TypeValidation, "Department does not exist"},
+		{"precondition", errs.PreconditionFailed("Employee e1 has been modified"), http.StatusPreconditionFailed, httperror.TypePrecondition, "Employee e1 has been modified"},
+		{"wrapped kind", fmt.Errorf("linking: %w", errs.NotFound("Employee e2 not found")), http.StatusNotFound, httperror.TypeNotFound, "linking: Employee e2 not found"},
+		// The causes of server-side failures are not shown to clients
+		{"storage", errs.Storage(errors.New("disk full")), http.StatusInternalServerError, httperror.TypeStorage, ""},
+		{"unclassified", errors.New("boom"), http.StatusInternalServerError, "about:blank", ""},
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, httperror.TypeTimeout, "The request did not complete in time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			httperror.Write(w, httptest.NewRequest(http.MethodGet, "/employees/e1", nil), tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != httperror.ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, httperror.ContentType)
+			}
+			var p httperror.Problem
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			want := httperror.Problem{
+				Type:     tt.wantType,
+				Title:    http.StatusText(tt.wantStatus),
+				Status:   tt.wantStatus,
+				Detail:   tt.wantDetail,
+				Instance: "/employees/e1",
+			}
+			if p.Type != want.Type || p.Title != want.Title || p.Status != want.Status || p.Detail != want.Detail || p.Instance != want.Instance {
+				t.Errorf("problem = %+v, want %+v", p, want)
+			}
+		})
+	}
+}
+
+func TestWriteListsFieldErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := errs.Invalid(
+		errs.FieldError{Field: "name", Message: "is required"},
+		errs.FieldError{Field: "email", Message: "must be an email address"},
+	)
+	httperror.Write(w, httptest.NewRequest(http.MethodPost, "/employees", nil), err)
+
+	var body map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	fields, ok := body["errors"].([]any)
+	if w.Code != http.StatusUnprocessableEntity || !ok || len(fields) != 2 {
+		t.Fatalf("status %d, body %v; want 422 with 2 field errors", w.Code, body)
+	}
+	first, _ := fields[0].(map[string]any)
+	if first["field"] != "name" || first["message"] != "is required" {
+		t.Errorf("first field error = %v", first)
+	}
+}
+
+func TestBadRequestAndStatus(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/employees?limit=0", nil)
+	w := httptest.NewRecorder()
+	httperror.BadRequest(w, r, "limit must be an integer between 1 and 100")
+	var p httperror.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || p.Type != httperror.TypeBadRequest || p.Errors != nil {
+		t.Errorf("bad request = %d %+v", w.Code, p)
+	}
+
+	w = httptest.NewRecorder()
+	httperror.Status(w, r, http.StatusUnsupportedMediaType, "unsupported patch media type")
+	if w.Code != http.StatusUnsupportedMediaType || w.Header().Get("Content-Type") != httperror.ContentType {
+		t.Errorf("status = %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
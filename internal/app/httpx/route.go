@@ -0,0 +1,22 @@
+package httpx
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// Unmatched is the route of requests no route of the router matches, which
+// are answered with 404 or 405.
+const Unmatched = "unmatched"
+
+// Route returns the template of the route of router matching r, such as
+// /employees/{id}, or Unmatched.
+func Route(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if router.Match(r, &match) && match.Route != nil {
+		if tmpl, err := match.Route.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return Unmatched
+}
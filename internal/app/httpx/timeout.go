@@ -0,0 +1,23 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timeout bounds the context of every request by d, so repositories stop
+// working on requests that can no longer be answered in time. A zero d
+// leaves requests unbounded.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/evanphx/json-patch/v5"
+	"mime"
+	"template-golang/internal/domain/errs"
+)
+
+// Media types accepted by PATCH endpoints.
+const (
+	MergePatch = "application/merge-patch+json"
+	JSONPatch  = "application/json-patch+json"
+)
+
+// Accepted lists the media types for the Accept-Patch header.
+const Accepted = MergePatch + ", " + JSONPatch
+
+var ErrUnsupportedMediaType = errors.New("unsupported patch media type")
+
+// Patch is a parsed RFC 7396 merge patch or RFC 6902 JSON patch.
+type Patch struct {
+	mediaType string
+	body      []byte
+	ops       jsonpatch.Patch
+}
+
+// Parse validates body as a patch document of the given Content-Type.
+func Parse(contentType string, body []byte) (Patch, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Patch{}, ErrUnsupportedMediaType
+	}
+	p := Patch{mediaType: mediaType, body: body}
+	switch mediaType {
+	case MergePatch:
+		if !json.Valid(body) || !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+			return Patch{}, fmt.Errorf("merge patch must be a JSON object")
+		}
+	case JSONPatch:
+		if p.ops, err = jsonpatch.DecodePatch(body); err != nil {
+			return Patch{}, fmt.Errorf("invalid JSON patch: %w", err)
+		}
+	default:
+		return Patch{}, ErrUnsupportedMediaType
+	}
+	return p, nil
+}
+
+// Apply patches the JSON representation of current and decodes the result
+// into target, rejecting fields target does not declare. A failed JSON patch
+// test operation is reported as a conflict.
+func (p Patch) Apply(current, target any) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	if p.mediaType == MergePatch {
+		patched, err = jsonpatch.MergePatch(doc, p.body)
+	} else {
+		patched, err = p.ops.Apply(doc)
+	}
+	switch {
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return errs.Conflict("Patch test failed: %v", err)
+	case err != nil:
+		return errs.Validation("Patch cannot be applied: %v", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		return errs.Validation("Patched document is invalid: %v", err)
+	}
+	return nil
+}
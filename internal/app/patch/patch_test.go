@@ -0,0 +1,104 @@
+package patch_test
+
+import (
+	"errors"
+	"template-golang/internal/app/patch"
+	"template-golang/internal/domain/errs"
+	"testing"
+)
+
+type record struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+func TestMergeAndJSONPatchOnTheSameDocument(t *testing.T) {
+	current := record{ID: "e1", Name: "Ada", Tags: []string{"a", "b"}, Notes: "draft"}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        record
+	}{
+		// A merge patch replaces arrays whole and removes members set to null
+		{"merge patch", patch.MergePatch, `{"name":"Grace","tags":["c"],"notes":null}`, record{ID: "e1", Name: "Grace", Tags: []string{"c"}}},
+		// A JSON patch can address single array elements
+		{"JSON patch", patch.JSONPatch, `[{"op":"replace","path":"/name","value":"Grace"},{"op":"add","path":"/tags/1","value":"c"},{"op":"remove","path":"/notes"}]`, record{ID: "e1", Name: "Grace", Tags: []string{"a", "c", "b"}}},
+		{"media type parameters", patch.MergePatch + "; charset=utf-8", `{"name":"Grace"}`, record{ID: "e1", Name: "Grace", Tags: []string{"a", "b"}, Notes: "draft"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := patch.Parse(tt.contentType, []byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got record
+			if err := p.Apply(current, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != tt.want.ID || got.Name != tt.want.Name || got.Notes != tt.want.Notes || len(got.Tags) != len(tt.want.Tags) {
+				t.Fatalf("patched = %+v, want %+v", got, tt.want)
+			}
+			for i := range got.Tags {
+				if got.Tags[i] != tt.want.Tags[i] {
+					t.Errorf("patched tags = %v, want %v", got.Tags, tt.want.Tags)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		unsupported bool
+	}{
+		{"plain JSON", "application/json", `{"name":"Grace"}`, true},
+		{"missing content type", "", `{"name":"Grace"}`, true},
+		{"merge patch array", patch.MergePatch, `[{"name":"Grace"}]`, false},
+		{"malformed merge patch", patch.MergePatch, `{"name":`, false},
+		{"JSON patch object", patch.JSONPatch, `{"op":"remove","path":"/name"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := patch.Parse(tt.contentType, []byte(tt.body))
+			if err == nil {
+				t.Fatal("parsed an invalid patch")
+			}
+			if errors.Is(err, patch.ErrUnsupportedMediaType) != tt.unsupported {
+				t.Errorf("err = %v, unsupported media type %v", err, tt.unsupported)
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	current := record{ID: "e1", Name: "Ada"}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        error
+	}{
+		{"failed test operation", patch.JSONPatch, `[{"op":"test","path":"/name","value":"Grace"},{"op":"replace","path":"/name","value":"Linus"}]`, errs.ErrConflict},
+		{"missing path", patch.JSONPatch, `[{"op":"remove","path":"/missing"}]`, errs.ErrValidation},
+		{"unknown field", patch.MergePatch, `{"nickname":"Countess"}`, errs.ErrValidation},
+		{"wrong type", patch.MergePatch, `{"name":42}`, errs.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := patch.Parse(tt.contentType, []byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got record
+			if err := p.Apply(current, &got); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
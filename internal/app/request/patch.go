@@ -0,0 +1,18 @@
+package request
+
+import (
+	"io"
+	"net/http"
+	"template-golang/internal/app/patch"
+)
+
+const maxPatchSize = 1 << 20
+
+// Patch reads the request body as a patch document of the request Content-Type.
+func Patch(r *http.Request) (patch.Patch, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize))
+	if err != nil {
+		return patch.Patch{}, err
+	}
+	return patch.Parse(r.Header.Get("Content-Type"), body)
+}
@@ -0,0 +1,44 @@
+package request_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"template-golang/internal/app/patch"
+	"template-golang/internal/app/request"
+	"testing"
+)
+
+func TestPatchUsesTheContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		unsupported bool
+	}{
+		{patch.MergePatch, `{"name":"Grace"}`, false},
+		{patch.JSONPatch, `[{"op":"replace","path":"/name","value":"Grace"}]`, false},
+		{"application/json", `{"name":"Grace"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/employees/e1", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			p, err := request.Patch(r)
+			if errors.Is(err, patch.ErrUnsupportedMediaType) != tt.unsupported {
+				t.Fatalf("err = %v, unsupported media type %v", err, tt.unsupported)
+			}
+			if tt.unsupported {
+				return
+			}
+			var got struct {
+				Name string `json:"name"`
+			}
+			if err := p.Apply(struct {
+				Name string `json:"name"`
+			}{"Ada"}, &got); err != nil || got.Name != "Grace" {
+				t.Errorf("patched name %q, err %v", got.Name, err)
+			}
+		})
+	}
+}
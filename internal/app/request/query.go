@@ -0,0 +1,56 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"template-golang/internal/domain/listing"
+)
+
+// ListQuery parses the limit, after and sort parameters shared by list
+// endpoints. The first of sorts is the default order.
+func ListQuery(r *http.Request, sorts []string) (listing.Query, error) {
+	values := r.URL.Query()
+	q := listing.Query{
+		Limit: listing.DefaultLimit,
+		After: values.Get("after"),
+		Sort:  sorts[0],
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > listing.MaxLimit {
+			return q, fmt.Errorf("limit must be an integer between 1 and %d", listing.MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		if !slices.Contains(sorts, sort) {
+			return q, fmt.Errorf("sort must be one of %s", strings.Join(sorts, ", "))
+		}
+		q.Sort = sort
+	}
+
+	return q, nil
+}
+
+// Expand parses the expand parameter, a comma separated list of the related
+// records to embed, which may be repeated. Every field must be one of fields.
+func Expand(r *http.Request, fields []string) ([]string, error) {
+	var expand []string
+	for _, value := range r.URL.Query()["expand"] {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if !slices.Contains(fields, field) {
+				return nil, fmt.Errorf("expand must be a list of %s", strings.Join(fields, ", "))
+			}
+			if !slices.Contains(expand, field) {
+				expand = append(expand, field)
+			}
+		}
+	}
+	return expand, nil
+}
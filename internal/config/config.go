@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// Config is the runtime configuration of the service.
+type Config struct {
+	HTTP HTTPConfig `json:"http" yaml:"http"`
+	// Storage names the backend holding the records: bolt, memory, sqlite
+	// or postgres.
+	Storage  string         `json:"storage" yaml:"storage"`
+	Bolt     BoltConfig     `json:"bolt" yaml:"bolt"`
+	SQLite   SQLiteConfig   `json:"sqlite" yaml:"sqlite"`
+	Postgres PostgresConfig `json:"postgres" yaml:"postgres"`
+	Log      LogConfig      `json:"log" yaml:"log"`
+}
+
+type HTTPConfig struct {
+	Addr         string   `json:"addr" yaml:"addr"`
+	ReadTimeout  Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout Duration `json:"write_timeout" yaml:"write_timeout"`
+	// RequestTimeout bounds the context of each request; zero disables it.
+	RequestTimeout Duration `json:"request_timeout" yaml:"request_timeout"`
+	// ShutdownTimeout bounds the time given to in-flight requests on SIGTERM.
+	ShutdownTimeout Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+}
+
+type BoltConfig struct {
+	Path        string   `json:"path" yaml:"path"`
+	OpenTimeout Duration `json:"open_timeout" yaml:"open_timeout"`
+}
+
+type SQLiteConfig struct {
+	Path string `json:"path" yaml:"path"`
+	// BusyTimeout is how long a writer waits for the database lock.
+	BusyTimeout Duration `json:"busy_timeout" yaml:"busy_timeout"`
+}
+
+type PostgresConfig struct {
+	DSN string `json:"dsn" yaml:"dsn"`
+	// MaxOpenConns bounds the connections of the pool; zero means unlimited.
+	MaxOpenConns int `json:"max_open_conns" yaml:"max_open_conns"`
+	MaxIdleConns int `json:"max_idle_conns" yaml:"max_idle_conns"`
+	// ConnMaxLifetime and ConnMaxIdleTime recycle connections; zero keeps
+	// them forever.
+	ConnMaxLifetime Duration `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+}
+
+type LogConfig struct {
+	Level slog.Level `json:"level" yaml:"level"`
+	// Format is either "json" or "text".
+	Format string `json:"format" yaml:"format"`
+}
+
+// Storage backends.
+const (
+	StorageBolt     = "bolt"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+)
+
+// Default returns the configuration used when nothing overrides it.
+func Default() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Addr:         ":8080",
+			ReadTimeout:  Duration(15 * time.Second),
+			WriteTimeout: Duration(15 * time.Second),
+			// Expires before the write timeout so clients get a 503.
+			RequestTimeout: Duration(10 * time.Second),
+			// Below the 10s Docker waits before sending SIGKILL.
+			ShutdownTimeout: Duration(8 * time.Second),
+		},
+		Storage: StorageBolt,
+		Bolt: BoltConfig{
+			Path:        "my.db",
+			OpenTimeout: Duration(5 * time.Second),
+		},
+		SQLite: SQLiteConfig{
+			Path:        "hr.sqlite",
+			BusyTimeout: Duration(5 * time.Second),
+		},
+		Postgres: PostgresConfig{
+			DSN:             "postgres://localhost:5432/hr?sslmode=disable",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			ConnMaxIdleTime: Duration(5 * time.Minute),
+		},
+		Log: LogConfig{
+			Level:  slog.LevelInfo,
+			Format: "json",
+		},
+	}
+}
+
+// Validate reports the first setting that cannot be used.
+func (c Config) Validate() error {
+	if _, _, err := net.SplitHostPort(c.HTTP.Addr); err != nil {
+		return fmt.Errorf("http.addr: %w", err)
+	}
+	if c.HTTP.ReadTimeout < 0 {
+		return fmt.Errorf("http.read_timeout must not be negative")
+	}
+	if c.HTTP.WriteTimeout < 0 {
+		return fmt.Errorf("http.write_timeout must not be negative")
+	}
+	if c.HTTP.RequestTimeout < 0 {
+		return fmt.Errorf("http.request_timeout must not be negative")
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		return fmt.Errorf("http.shutdown_timeout must be positive")
+	}
+	switch c.Storage {
+	case StorageBolt, StorageMemory, StorageSQLite, StoragePostgres:
+	default:
+		return fmt.Errorf("storage must be %s, %s, %s or %s", StorageBolt, StorageMemory, StorageSQLite, StoragePostgres)
+	}
+	if c.Bolt.Path == "" {
+		return fmt.Errorf("bolt.path is required")
+	}
+	if c.Bolt.OpenTimeout <= 0 {
+		return fmt.Errorf("bolt.open_timeout must be positive")
+	}
+	if c.SQLite.Path == "" {
+		return fmt.Errorf("sqlite.path is required")
+	}
+	if c.SQLite.BusyTimeout < 0 {
+		return fmt.Errorf("sqlite.busy_timeout must not be negative")
+	}
+	if c.Storage == StoragePostgres && c.Postgres.DSN == "" {
+		return fmt.Errorf("postgres.dsn is required")
+	}
+	if c.Postgres.MaxOpenConns < 0 || c.Postgres.MaxIdleConns < 0 {
+		return fmt.Errorf("postgres connection limits must not be negative")
+	}
+	if c.Postgres.ConnMaxLifetime < 0 || c.Postgres.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("postgres connection lifetimes must not be negative")
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		return fmt.Errorf("log.format must be json or text")
+	}
+	return nil
+}
+
+// Duration is a time.Duration written as "15s" in files, flags and the environment.
+type Duration time.Duration
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// setting binds one configuration value to its environment variable and flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	value func(*Config) any
+}
+
+var settings = []setting{
+	{"LISTEN_ADDR", "listen-addr", "HTTP listen address", func(c *Config) any { return &c.HTTP.Addr }},
+	{"HTTP_READ_TIMEOUT", "read-timeout", "HTTP read timeout", func(c *Config) any { return &c.HTTP.ReadTimeout }},
+	{"HTTP_WRITE_TIMEOUT", "write-timeout", "HTTP write timeout", func(c *Config) any { return &c.HTTP.WriteTimeout }},
+	{"HTTP_REQUEST_TIMEOUT", "request-timeout", "deadline of each request, 0 for none", func(c *Config) any { return &c.HTTP.RequestTimeout }},
+	{"HTTP_SHUTDOWN_TIMEOUT", "shutdown-timeout", "time given to in-flight requests on shutdown", func(c *Config) any { return &c.HTTP.ShutdownTimeout }},
+	{"STORAGE", "storage", "storage backend: bolt, memory, sqlite or postgres", func(c *Config) any { return &c.Storage }},
+	{"BOLTDB_PATH", "db-path", "path of the bbolt database file", func(c *Config) any { return &c.Bolt.Path }},
+	{"BOLTDB_OPEN_TIMEOUT", "db-open-timeout", "time to wait for the bbolt file lock", func(c *Config) any { return &c.Bolt.OpenTimeout }},
+	{"SQLITE_PATH", "sqlite-path", "path of the SQLite database file", func(c *Config) any { return &c.SQLite.Path }},
+	{"SQLITE_BUSY_TIMEOUT", "sqlite-busy-timeout", "time a SQLite writer waits for the database lock", func(c *Config) any { return &c.SQLite.BusyTimeout }},
+	{"POSTGRES_DSN", "postgres-dsn", "PostgreSQL connection string", func(c *Config) any { return &c.Postgres.DSN }},
+	{"POSTGRES_MAX_OPEN_CONNS", "postgres-max-open-conns", "maximum open PostgreSQL connections, 0 for unlimited", func(c *Config) any { return &c.Postgres.MaxOpenConns }},
+	{"POSTGRES_MAX_IDLE_CONNS", "postgres-max-idle-conns", "maximum idle PostgreSQL connections", func(c *Config) any { return &c.Postgres.MaxIdleConns }},
+	{"POSTGRES_CONN_MAX_LIFETIME", "postgres-conn-max-lifetime", "time after which a PostgreSQL connection is replaced, 0 for never", func(c *Config) any { return &c.Postgres.ConnMaxLifetime }},
+	{"POSTGRES_CONN_MAX_IDLE_TIME", "postgres-conn-max-idle-time", "time after which an idle PostgreSQL connection is closed, 0 for never", func(c *Config) any { return &c.Postgres.ConnMaxIdleTime }},
+	{"LOG_LEVEL", "log-level", "log level: debug, info, warn or error", func(c *Config) any { return &c.Log.Level }},
+	{"LOG_FORMAT", "log-format", "log format: json or text", func(c *Config) any { return &c.Log.Format }},
+}
+
+// Options are the command line switches that are not configuration values.
+type Options struct {
+	PrintConfig bool
+}
+
+// Load builds the configuration from, in increasing precedence, the defaults,
+// the optional YAML or JSON file named by --config or CONFIG_FILE, the
+// environment and the command line flags. The result is validated.
+func Load(args []string, getenv func(string) string) (Config, Options, error) {
+	return LoadFlags(flag.NewFlagSet("template-golang", flag.ContinueOnError), args, getenv)
+}
+
+// LoadFlags is Load parsing args with fs, on which commands define their
+// own flags beside the configuration ones.
+func LoadFlags(fs *flag.FlagSet, args []string, getenv func(string) string) (Config, Options, error) {
+	var opts Options
+	flags := map[string]*string{}
+	configFile := fs.String("config", getenv("CONFIG_FILE"), "optional YAML or JSON configuration file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration and exit")
+	for _, s := range settings {
+		flags[s.flag] = fs.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, opts, err
+	}
+
+	cfg := Default()
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return Config{}, opts, err
+		}
+	}
+	for _, s := range settings {
+		if v := getenv(s.env); v != "" {
+			if err := set(s.value(&cfg), v); err != nil {
+				return Config{}, opts, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if err = set(s.value(&cfg), *flags[s.flag]); err != nil {
+					err = fmt.Errorf("-%s: %w", s.flag, err)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return Config{}, opts, err
+	}
+
+	return cfg, opts, cfg.Validate()
+}
+
+// YAML renders the configuration for --print-config, with the password of
+// the PostgreSQL connection string hidden.
+func (c Config) YAML() ([]byte, error) {
+	c.Postgres.DSN = redactDSN(c.Postgres.DSN)
+	return yaml.Marshal(c)
+}
+
+// dsnPassword matches the password of a keyword/value connection string,
+// or of the query of a URL one.
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s&]+)`)
+
+// redactDSN replaces the password of a PostgreSQL connection string, in URL
+// or keyword/value form, with "xxxxx".
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		dsn = u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}xxxxx")
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch filepath.Ext(path) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func set(target any, value string) error {
+	switch t := target.(type) {
+	case *string:
+		*t = value
+		return nil
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*t = n
+		return nil
+	case encoding.TextUnmarshaler:
+		return t.UnmarshalText([]byte(value))
+	default:
+		return fmt.Errorf("unsupported setting type %T", target)
+	}
+}
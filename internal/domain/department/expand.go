@@ -0,0 +1,16 @@
+package department
+
+import "template-golang/internal/domain/employee"
+
+// Related records a department response can embed with ?expand=.
+const (
+	ExpandHead = "head"
+)
+
+var Expansions = []string{ExpandHead}
+
+// Expanded is a department with the related records asked for embedded.
+type Expanded struct {
+	Department
+	Head *employee.Employee `json:"head,omitempty"`
+}
@@ -0,0 +1,17 @@
+package department
+
+import "template-golang/internal/domain/listing"
+
+// Sort orders accepted by ListQuery.
+const (
+	SortByID   = "id"
+	SortByName = "name"
+)
+
+var Sorts = []string{SortByID, SortByName}
+
+// ListQuery filters departments by name prefix, compared case-insensitively.
+type ListQuery struct {
+	listing.Query
+	NamePrefix string
+}
@@ -3,4 +3,10 @@ package department
 type Department struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
+	// ParentID is the department containing this one, empty at the top of
+	// the company.
+	ParentID string `json:"parent_id,omitempty"`
+	// HeadID is the employee running the department, one of its own staff.
+	HeadID  string `json:"head_id,omitempty"`
+	Version int64  `json:"version"`
 }
@@ -0,0 +1,33 @@
+package department
+
+import "fmt"
+
+// DeletePolicy decides what happens to the employees of a department being deleted.
+type DeletePolicy string
+
+const (
+	// DeleteReject refuses to delete a department that still has employees.
+	DeleteReject DeletePolicy = "reject"
+	// DeleteCascade deletes the employees together with the department,
+	// unless employees of other departments report to them.
+	DeleteCascade DeletePolicy = "cascade"
+	// DeleteReassign moves the employees to another department first.
+	DeleteReassign DeletePolicy = "reassign"
+)
+
+type DeleteOptions struct {
+	Policy     DeletePolicy
+	ReassignTo string
+}
+
+// ParseDeletePolicy converts a query parameter into a DeletePolicy, defaulting to DeleteReject.
+func ParseDeletePolicy(s string) (DeletePolicy, error) {
+	switch p := DeletePolicy(s); p {
+	case "":
+		return DeleteReject, nil
+	case DeleteReject, DeleteCascade, DeleteReassign:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown delete policy %q", s)
+	}
+}
@@ -0,0 +1,27 @@
+package department
+
+// Node is a department with the departments it contains.
+type Node struct {
+	Department
+	Children []Node `json:"children"`
+}
+
+// Tree arranges the descendants of root under it. Descendants whose parent
+// is neither root nor another descendant are left out, and siblings keep the
+// order they have in descendants.
+func Tree(root Department, descendants []Department) Node {
+	children := map[string][]Department{}
+	for _, d := range descendants {
+		children[d.ParentID] = append(children[d.ParentID], d)
+	}
+
+	var build func(d Department) Node
+	build = func(d Department) Node {
+		node := Node{Department: d, Children: make([]Node, 0, len(children[d.ID]))}
+		for _, child := range children[d.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+	return build(root)
+}
@@ -0,0 +1,44 @@
+package department
+
+import (
+	"strings"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/validate"
+)
+
+const (
+	MaxIDLength   = 64
+	MaxNameLength = 100
+)
+
+// Normalize trims the surrounding whitespace of every text field.
+func (d *Department) Normalize() {
+	d.ID = strings.TrimSpace(d.ID)
+	d.Name = strings.TrimSpace(d.Name)
+	d.ParentID = strings.TrimSpace(d.ParentID)
+	d.HeadID = strings.TrimSpace(d.HeadID)
+}
+
+// Validate reports every field of the department that breaks the domain rules.
+func (d Department) Validate() error {
+	var v validate.Validator
+
+	v.MaxLength("id", d.ID, MaxIDLength)
+	v.Matches("id", d.ID, validate.Identifier, "letters, digits, '-' and '_'")
+
+	if v.Required("name", d.Name) {
+		v.MaxLength("name", d.Name, MaxNameLength)
+		v.Matches("name", d.Name, validate.Title, "letters, digits, spaces and common punctuation")
+	}
+
+	v.MaxLength("parent_id", d.ParentID, MaxIDLength)
+	v.Matches("parent_id", d.ParentID, validate.Identifier, "letters, digits, '-' and '_'")
+	if d.ParentID != "" && d.ParentID == d.ID {
+		v.Add("parent_id", "must not be the department itself")
+	}
+
+	v.MaxLength("head_id", d.HeadID, employee.MaxIDLength)
+	v.Matches("head_id", d.HeadID, validate.Identifier, "letters, digits, '-' and '_'")
+
+	return v.Err()
+}
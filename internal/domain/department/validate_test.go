@@ -0,0 +1,66 @@
+package department_test
+
+import (
+	"errors"
+	"strings"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/errs"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		dept department.Department
+		want []errs.FieldError
+	}{
+		{"valid", department.Department{ID: "eng", Name: "Research & Development (EU)"}, nil},
+		{"without ID", department.Department{Name: "Engineering"}, nil},
+		{"missing name", department.Department{ID: "eng", Name: "  "}, []errs.FieldError{
+			{Field: "name", Message: "is required"},
+		}},
+		{"long name", department.Department{ID: "eng", Name: strings.Repeat("n", department.MaxNameLength+1)}, []errs.FieldError{
+			{Field: "name", Message: "must be at most 100 characters"},
+		}},
+		{"every violation at once", department.Department{ID: strings.Repeat("d", department.MaxIDLength) + "!", Name: "<Engineering>"}, []errs.FieldError{
+			{Field: "id", Message: "must be at most 64 characters"},
+			{Field: "id", Message: "must contain only letters, digits, '-' and '_'"},
+			{Field: "name", Message: "must contain only letters, digits, spaces and common punctuation"},
+		}},
+		{"own parent", department.Department{ID: "eng", Name: "Engineering", ParentID: "eng"}, []errs.FieldError{
+			{Field: "parent_id", Message: "must not be the department itself"},
+		}},
+		{"references", department.Department{ID: "eng", Name: "Engineering", ParentID: "r&d", HeadID: "e 1"}, []errs.FieldError{
+			{Field: "parent_id", Message: "must contain only letters, digits, '-' and '_'"},
+			{Field: "head_id", Message: "must contain only letters, digits, '-' and '_'"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []errs.FieldError
+			if err := tt.dept.Validate(); err != nil {
+				var e *errs.Error
+				if !errors.As(err, &e) || !errors.Is(err, errs.ErrValidation) {
+					t.Fatalf("err = %v, want a validation error", err)
+				}
+				got = e.Fields
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("field errors = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("field error %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	d := department.Department{ID: " eng\t", Name: " Engineering  ", ParentID: " rd ", HeadID: "\ne1"}
+	d.Normalize()
+	if d.ID != "eng" || d.Name != "Engineering" || d.ParentID != "rd" || d.HeadID != "e1" {
+		t.Errorf("normalized = %+v", d)
+	}
+}
@@ -0,0 +1,21 @@
+package employee
+
+import "template-golang/internal/domain/listing"
+
+// Sort orders accepted by ListQuery.
+const (
+	SortByID       = "id"
+	SortByName     = "name"
+	SortByPosition = "position"
+)
+
+var Sorts = []string{SortByID, SortByName, SortByPosition}
+
+// ListQuery filters employees by exact position and by name prefix, both
+// compared case-insensitively, and by status.
+type ListQuery struct {
+	listing.Query
+	Position   string
+	NamePrefix string
+	Status     Status
+}
@@ -5,4 +5,36 @@ type Employee struct {
 	Name         string `json:"name"`
 	Position     string `json:"position"`
 	DepartmentId string `json:"department:id"`
+	// ManagerID is the employee this one reports to, empty at the top of
+	// the organization.
+	ManagerID string `json:"manager_id,omitempty"`
+	// Email is unique among employees and stored in lower case.
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	// HireDate and TerminationDate are calendar dates in DateLayout.
+	HireDate        string         `json:"hire_date,omitempty"`
+	TerminationDate string         `json:"termination_date,omitempty"`
+	EmploymentType  EmploymentType `json:"employment_type,omitempty"`
+	Location        string         `json:"location,omitempty"`
+	CostCenter      string         `json:"cost_center,omitempty"`
+	// Status is changed by applying a Transition, effective on StatusDate
+	// for StatusReason.
+	Status       Status `json:"status"`
+	StatusDate   string `json:"status_date,omitempty"`
+	StatusReason string `json:"status_reason,omitempty"`
+	Version      int64  `json:"version"`
 }
+
+// EmploymentType is the contract under which an employee works.
+type EmploymentType string
+
+const (
+	FullTime   EmploymentType = "full-time"
+	PartTime   EmploymentType = "part-time"
+	Contractor EmploymentType = "contractor"
+)
+
+var EmploymentTypes = []EmploymentType{FullTime, PartTime, Contractor}
+
+// DateLayout is the format of the dates of an employee, which sort as text.
+const DateLayout = "2006-01-02"
@@ -0,0 +1,36 @@
+package employee
+
+// OrgNode is an employee with the employees reporting to them.
+type OrgNode struct {
+	Employee
+	Reports []OrgNode `json:"reports"`
+}
+
+// OrgChart arranges employees into the trees of their reporting lines. The
+// roots are the employees without a manager among employees, and siblings
+// keep the order they have in employees.
+func OrgChart(employees []Employee) []OrgNode {
+	known := make(map[string]bool, len(employees))
+	for _, e := range employees {
+		known[e.ID] = true
+	}
+	reports := map[string][]Employee{}
+	var roots []Employee
+	for _, e := range employees {
+		if e.ManagerID == "" || !known[e.ManagerID] {
+			roots = append(roots, e)
+			continue
+		}
+		reports[e.ManagerID] = append(reports[e.ManagerID], e)
+	}
+
+	var build func(level []Employee) []OrgNode
+	build = func(level []Employee) []OrgNode {
+		nodes := make([]OrgNode, 0, len(level))
+		for _, e := range level {
+			nodes = append(nodes, OrgNode{Employee: e, Reports: build(reports[e.ID])})
+		}
+		return nodes
+	}
+	return build(roots)
+}
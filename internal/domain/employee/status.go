@@ -0,0 +1,131 @@
+package employee
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/validate"
+	"time"
+)
+
+// Status is the stage of an employee in the employment lifecycle.
+type Status string
+
+const (
+	Candidate  Status = "candidate"
+	Onboarding Status = "onboarding"
+	Active     Status = "active"
+	OnLeave    Status = "on_leave"
+	Terminated Status = "terminated"
+	Rehired    Status = "rehired"
+)
+
+var Statuses = []Status{Candidate, Onboarding, Active, OnLeave, Terminated, Rehired}
+
+// InitialStatuses are the statuses a new employee may be created with.
+// Employees created without one are active, like those stored before the
+// lifecycle existed.
+var InitialStatuses = []Status{Candidate, Onboarding, Active}
+
+const MaxStatusReasonLength = 500
+
+// ErrStatusChanged is returned when an update changes the status or the
+// employment dates of an employee instead of going through a Transition.
+var ErrStatusChanged = errs.Conflict("Status and employment dates can only be changed through transitions")
+
+// Transition is a step of the lifecycle, moving an employee from one of
+// From to To.
+type Transition struct {
+	Name string
+	From []Status
+	To   Status
+}
+
+var (
+	Onboard   = Transition{Name: "onboard", From: []Status{Candidate}, To: Onboarding}
+	Activate  = Transition{Name: "activate", From: []Status{Onboarding, OnLeave, Rehired}, To: Active}
+	Leave     = Transition{Name: "leave", From: []Status{Active}, To: OnLeave}
+	Terminate = Transition{Name: "terminate", From: []Status{Candidate, Onboarding, Active, OnLeave, Rehired}, To: Terminated}
+	Rehire    = Transition{Name: "rehire", From: []Status{Terminated}, To: Rehired}
+)
+
+var Transitions = []Transition{Onboard, Activate, Leave, Terminate, Rehire}
+
+// TransitionNamed returns the transition called name.
+func TransitionNamed(name string) (Transition, bool) {
+	i := slices.IndexFunc(Transitions, func(t Transition) bool { return t.Name == name })
+	if i < 0 {
+		return Transition{}, false
+	}
+	return Transitions[i], true
+}
+
+// ParseStatus converts a query parameter into a Status, empty when s is.
+func ParseStatus(s string) (Status, error) {
+	status := Status(s)
+	if s != "" && !slices.Contains(Statuses, status) {
+		return "", fmt.Errorf("status must be one of %s", joinStatuses(Statuses))
+	}
+	return status, nil
+}
+
+// Apply moves e through t, effective on date, in DateLayout. Terminating
+// sets the termination date, while onboarding and rehiring set the hire
+// date, the latter clearing the termination. A transition that does not
+// start from the status of e is a conflict, and one effective before the
+// previous transition, or a termination before the hire date, is invalid.
+func (e *Employee) Apply(t Transition, date, reason string) error {
+	date, reason = strings.TrimSpace(date), strings.TrimSpace(reason)
+	var v validate.Validator
+	if v.Required("effective_date", date) {
+		parseDate(&v, "effective_date", date)
+	}
+	v.MaxLength("reason", reason, MaxStatusReasonLength)
+	if err := v.Err(); err != nil {
+		return err
+	}
+	if !slices.Contains(t.From, e.Status) {
+		return errs.Conflict("Employee %s cannot %s while %s", e.ID, t.Name, e.Status)
+	}
+	// Dates in DateLayout compare as text
+	if date < e.StatusDate {
+		v.Add("effective_date", "must not be before the last status change, on %s", e.StatusDate)
+	}
+	if t.To == Terminated && date < e.HireDate {
+		v.Add("effective_date", "must not be before the hire date, %s", e.HireDate)
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	switch t.To {
+	case Onboarding:
+		if e.HireDate == "" {
+			e.HireDate = date
+		}
+	case Terminated:
+		e.TerminationDate = date
+	case Rehired:
+		e.HireDate = date
+		e.TerminationDate = ""
+	}
+	e.Status = t.To
+	e.StatusDate = date
+	e.StatusReason = reason
+	return nil
+}
+
+// Today returns the current date in DateLayout, the default effective date
+// of a transition.
+func Today() string {
+	return time.Now().UTC().Format(DateLayout)
+}
+
+func joinStatuses(statuses []Status) string {
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,119 @@
+package employee_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"testing"
+)
+
+func TestApplyFollowsTheLifecycle(t *testing.T) {
+	allowed := map[string][]employee.Status{
+		"onboard":   {employee.Candidate},
+		"activate":  {employee.Onboarding, employee.OnLeave, employee.Rehired},
+		"leave":     {employee.Active},
+		"terminate": {employee.Candidate, employee.Onboarding, employee.Active, employee.OnLeave, employee.Rehired},
+		"rehire":    {employee.Terminated},
+	}
+	for _, tr := range employee.Transitions {
+		for _, from := range employee.Statuses {
+			t.Run(tr.Name+" from "+string(from), func(t *testing.T) {
+				e := employee.Employee{ID: "e1", Status: from, StatusDate: "2024-01-01"}
+				err := e.Apply(tr, "2024-02-01", "planned")
+				if !slices.Contains(allowed[tr.Name], from) {
+					if !errors.Is(err, errs.ErrConflict) || e.Status != from {
+						t.Errorf("err = %v, status %s; want a conflict leaving %s", err, e.Status, from)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if e.Status != tr.To || e.StatusDate != "2024-02-01" || e.StatusReason != "planned" {
+					t.Errorf("moved = %+v, want %s on 2024-02-01", e, tr.To)
+				}
+			})
+		}
+	}
+}
+
+func TestApplySetsEmploymentDates(t *testing.T) {
+	tests := []struct {
+		name            string
+		from            employee.Employee
+		transition      employee.Transition
+		wantHire        string
+		wantTermination string
+	}{
+		{"onboarding hires", employee.Employee{Status: employee.Candidate}, employee.Onboard, "2024-02-01", ""},
+		{"onboarding keeps a planned hire date", employee.Employee{Status: employee.Candidate, HireDate: "2024-03-01"}, employee.Onboard, "2024-03-01", ""},
+		{"terminating", employee.Employee{Status: employee.Active, HireDate: "2023-01-01"}, employee.Terminate, "2023-01-01", "2024-02-01"},
+		{"rehiring", employee.Employee{Status: employee.Terminated, HireDate: "2023-01-01", TerminationDate: "2023-06-30"}, employee.Rehire, "2024-02-01", ""},
+		{"leaving", employee.Employee{Status: employee.Active, HireDate: "2023-01-01"}, employee.Leave, "2023-01-01", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.from
+			if err := e.Apply(tt.transition, " 2024-02-01 ", ""); err != nil {
+				t.Fatal(err)
+			}
+			if e.HireDate != tt.wantHire || e.TerminationDate != tt.wantTermination {
+				t.Errorf("hire %q, termination %q; want %q, %q", e.HireDate, e.TerminationDate, tt.wantHire, tt.wantTermination)
+			}
+		})
+	}
+}
+
+func TestApplyRejects(t *testing.T) {
+	active := employee.Employee{ID: "e1", Status: employee.Active, HireDate: "2024-01-15", StatusDate: "2024-01-15"}
+	tests := []struct {
+		name       string
+		from       employee.Employee
+		transition employee.Transition
+		date       string
+		reason     string
+		want       []errs.FieldError
+	}{
+		{"missing date", active, employee.Leave, " ", "", []errs.FieldError{
+			{Field: "effective_date", Message: "is required"},
+		}},
+		{"malformed date and long reason", active, employee.Leave, "2024-13-01", strings.Repeat("r", employee.MaxStatusReasonLength+1), []errs.FieldError{
+			{Field: "effective_date", Message: "must be a date formatted as YYYY-MM-DD"},
+			{Field: "reason", Message: "must be at most 500 characters"},
+		}},
+		{"before the last change", active, employee.Leave, "2024-01-14", "", []errs.FieldError{
+			{Field: "effective_date", Message: "must not be before the last status change, on 2024-01-15"},
+		}},
+		{"termination before hire", employee.Employee{ID: "e1", Status: employee.Onboarding, HireDate: "2024-05-01", StatusDate: "2024-04-01"}, employee.Terminate, "2024-04-30", "", []errs.FieldError{
+			{Field: "effective_date", Message: "must not be before the hire date, 2024-05-01"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.from
+			got := fieldErrors(t, e.Apply(tt.transition, tt.date, tt.reason))
+			if len(got) != len(tt.want) {
+				t.Fatalf("field errors = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("field error %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+			if e != tt.from {
+				t.Errorf("rejected transition changed the employee to %+v", e)
+			}
+		})
+	}
+}
+
+func TestTransitionNamed(t *testing.T) {
+	if tr, ok := employee.TransitionNamed("rehire"); !ok || tr.To != employee.Rehired {
+		t.Errorf("rehire = %+v, %v", tr, ok)
+	}
+	if _, ok := employee.TransitionNamed("promote"); ok {
+		t.Error("found the unknown transition promote")
+	}
+}
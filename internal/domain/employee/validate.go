@@ -0,0 +1,115 @@
+package employee
+
+import (
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"template-golang/internal/domain/validate"
+	"time"
+)
+
+const (
+	MaxIDLength         = 64
+	MaxNameLength       = 100
+	MaxPositionLength   = 100
+	MaxEmailLength      = 254
+	MaxPhoneLength      = 32
+	MaxLocationLength   = 100
+	MaxCostCenterLength = 32
+)
+
+// phone matches international and local numbers with the usual separators.
+var phone = regexp.MustCompile(`^\+?[0-9][0-9 ().-]*$`)
+
+// Normalize trims the surrounding whitespace of every text field.
+func (e *Employee) Normalize() {
+	e.ID = strings.TrimSpace(e.ID)
+	e.Name = strings.TrimSpace(e.Name)
+	e.Position = strings.TrimSpace(e.Position)
+	e.DepartmentId = strings.TrimSpace(e.DepartmentId)
+	e.ManagerID = strings.TrimSpace(e.ManagerID)
+	e.Email = strings.ToLower(strings.TrimSpace(e.Email))
+	e.Phone = strings.TrimSpace(e.Phone)
+	e.HireDate = strings.TrimSpace(e.HireDate)
+	e.TerminationDate = strings.TrimSpace(e.TerminationDate)
+	e.EmploymentType = EmploymentType(strings.TrimSpace(string(e.EmploymentType)))
+	e.Location = strings.TrimSpace(e.Location)
+	e.CostCenter = strings.TrimSpace(e.CostCenter)
+	e.Status = Status(strings.TrimSpace(string(e.Status)))
+	e.StatusDate = strings.TrimSpace(e.StatusDate)
+	e.StatusReason = strings.TrimSpace(e.StatusReason)
+}
+
+// Validate reports every field of the employee that breaks the domain rules.
+func (e Employee) Validate() error {
+	var v validate.Validator
+
+	v.MaxLength("id", e.ID, MaxIDLength)
+	v.Matches("id", e.ID, validate.Identifier, "letters, digits, '-' and '_'")
+
+	if v.Required("name", e.Name) {
+		v.MaxLength("name", e.Name, MaxNameLength)
+		v.Matches("name", e.Name, validate.PersonName, "letters, spaces, apostrophes, periods and hyphens")
+	}
+
+	if v.Required("position", e.Position) {
+		v.MaxLength("position", e.Position, MaxPositionLength)
+		v.Matches("position", e.Position, validate.Title, "letters, digits, spaces and common punctuation")
+	}
+
+	if v.Required("department:id", e.DepartmentId) {
+		v.MaxLength("department:id", e.DepartmentId, MaxIDLength)
+		v.Matches("department:id", e.DepartmentId, validate.Identifier, "letters, digits, '-' and '_'")
+	}
+
+	v.MaxLength("manager_id", e.ManagerID, MaxIDLength)
+	v.Matches("manager_id", e.ManagerID, validate.Identifier, "letters, digits, '-' and '_'")
+	if e.ManagerID != "" && e.ManagerID == e.ID {
+		v.Add("manager_id", "must not be the employee itself")
+	}
+
+	v.MaxLength("email", e.Email, MaxEmailLength)
+	if e.Email != "" {
+		if addr, err := mail.ParseAddress(e.Email); err != nil || addr.Address != e.Email {
+			v.Add("email", "must be an email address")
+		}
+	}
+	v.MaxLength("phone", e.Phone, MaxPhoneLength)
+	v.Matches("phone", e.Phone, phone, "digits, spaces, '(', ')', '.', '-' and a leading '+'")
+
+	hired := parseDate(&v, "hire_date", e.HireDate)
+	terminated := parseDate(&v, "termination_date", e.TerminationDate)
+	if !hired.IsZero() && !terminated.IsZero() && terminated.Before(hired) {
+		v.Add("termination_date", "must not be before hire_date")
+	}
+
+	if e.EmploymentType != "" && !slices.Contains(EmploymentTypes, e.EmploymentType) {
+		v.Add("employment_type", "must be one of %s, %s or %s", FullTime, PartTime, Contractor)
+	}
+	v.MaxLength("location", e.Location, MaxLocationLength)
+	v.Matches("location", e.Location, validate.Title, "letters, digits, spaces and common punctuation")
+	v.MaxLength("cost_center", e.CostCenter, MaxCostCenterLength)
+	v.Matches("cost_center", e.CostCenter, validate.Identifier, "letters, digits, '-' and '_'")
+
+	if e.Status != "" && !slices.Contains(Statuses, e.Status) {
+		v.Add("status", "must be one of %s", joinStatuses(Statuses))
+	}
+	parseDate(&v, "status_date", e.StatusDate)
+	v.MaxLength("status_reason", e.StatusReason, MaxStatusReasonLength)
+
+	return v.Err()
+}
+
+// parseDate parses an optional date field, reporting it when it is not in
+// DateLayout.
+func parseDate(v *validate.Validator, field, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		v.Add(field, "must be a date formatted as YYYY-MM-DD")
+	}
+	return t
+}
@@ -0,0 +1,114 @@
+package employee_test
+
+import (
+	"errors"
+	"strings"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"testing"
+)
+
+// fieldErrors returns the field errors of a validation error, failing the
+// test for any other error.
+func fieldErrors(t *testing.T, err error) []errs.FieldError {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var e *errs.Error
+	if !errors.As(err, &e) || !errors.Is(err, errs.ErrValidation) {
+		t.Fatalf("err = %v, want a validation error", err)
+	}
+	return e.Fields
+}
+
+func valid() employee.Employee {
+	return employee.Employee{ID: "e1", Name: "Ada Lovelace", Position: "Engineer", DepartmentId: "eng"}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(e *employee.Employee)
+		want   []errs.FieldError
+	}{
+		{"valid", func(e *employee.Employee) {}, nil},
+		{"without ID", func(e *employee.Employee) { e.ID = "" }, nil},
+		{"names in any script", func(e *employee.Employee) { e.Name = "Zoë O'Brien-Łukasz Jr." }, nil},
+		{"lengths in characters", func(e *employee.Employee) { e.Name = strings.Repeat("é", employee.MaxNameLength) }, nil},
+		{"missing fields", func(e *employee.Employee) { e.Name, e.Position, e.DepartmentId = "", "", "" }, []errs.FieldError{
+			{Field: "name", Message: "is required"},
+			{Field: "position", Message: "is required"},
+			{Field: "department:id", Message: "is required"},
+		}},
+		{"blank name", func(e *employee.Employee) { e.Name = " \t" }, []errs.FieldError{
+			{Field: "name", Message: "is required"},
+		}},
+		{"long name", func(e *employee.Employee) { e.Name = strings.Repeat("a", employee.MaxNameLength+1) }, []errs.FieldError{
+			{Field: "name", Message: "must be at most 100 characters"},
+		}},
+		{"long ID", func(e *employee.Employee) { e.ID = strings.Repeat("e", employee.MaxIDLength+1) }, []errs.FieldError{
+			{Field: "id", Message: "must be at most 64 characters"},
+		}},
+		{"characters", func(e *employee.Employee) { e.ID, e.Name, e.Position = "e/1", "Ada <b>", "Engineer; DROP" }, []errs.FieldError{
+			{Field: "id", Message: "must contain only letters, digits, '-' and '_'"},
+			{Field: "name", Message: "must contain only letters, spaces, apostrophes, periods and hyphens"},
+			{Field: "position", Message: "must contain only letters, digits, spaces and common punctuation"},
+		}},
+		{"every violation at once", func(e *employee.Employee) {
+			e.ID, e.Name, e.Position, e.DepartmentId = "e 1", "", strings.Repeat("p", employee.MaxPositionLength+1), "eng!"
+		}, []errs.FieldError{
+			{Field: "id", Message: "must contain only letters, digits, '-' and '_'"},
+			{Field: "name", Message: "is required"},
+			{Field: "position", Message: "must be at most 100 characters"},
+			{Field: "department:id", Message: "must contain only letters, digits, '-' and '_'"},
+		}},
+		{"own manager", func(e *employee.Employee) { e.ManagerID = e.ID }, []errs.FieldError{
+			{Field: "manager_id", Message: "must not be the employee itself"},
+		}},
+		{"profile", func(e *employee.Employee) {
+			e.Email, e.Phone, e.HireDate, e.TerminationDate = "ada@example.com", "+44 (20) 7946-0958", "2024-01-15", "2024-06-30"
+			e.EmploymentType, e.Location, e.CostCenter = employee.PartTime, "London", "CC-42"
+		}, nil},
+		{"invalid profile", func(e *employee.Employee) {
+			e.Email, e.Phone, e.HireDate, e.EmploymentType = "Ada <ada@example.com>", "call me", "15/01/2024", "intern"
+		}, []errs.FieldError{
+			{Field: "email", Message: "must be an email address"},
+			{Field: "phone", Message: "must contain only digits, spaces, '(', ')', '.', '-' and a leading '+'"},
+			{Field: "hire_date", Message: "must be a date formatted as YYYY-MM-DD"},
+			{Field: "employment_type", Message: "must be one of full-time, part-time or contractor"},
+		}},
+		{"termination before hire", func(e *employee.Employee) { e.HireDate, e.TerminationDate = "2024-06-30", "2024-01-15" }, []errs.FieldError{
+			{Field: "termination_date", Message: "must not be before hire_date"},
+		}},
+		{"unknown status", func(e *employee.Employee) { e.Status = "retired" }, []errs.FieldError{
+			{Field: "status", Message: "must be one of candidate, onboarding, active, on_leave, terminated, rehired"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := valid()
+			tt.mutate(&e)
+			got := fieldErrors(t, e.Validate())
+			if len(got) != len(tt.want) {
+				t.Fatalf("field errors = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("field error %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	e := employee.Employee{ID: " e1 ", Name: "  Ada Lovelace\n", Position: "\tEngineer ", DepartmentId: " eng", Email: " Ada@Example.COM "}
+	e.Normalize()
+	if e.ID != "e1" || e.Name != "Ada Lovelace" || e.Position != "Engineer" || e.DepartmentId != "eng" || e.Email != "ada@example.com" {
+		t.Errorf("normalized = %+v", e)
+	}
+	if err := e.Validate(); err != nil {
+		t.Errorf("normalized employee is invalid: %v", err)
+	}
+}
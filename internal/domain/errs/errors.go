@@ -0,0 +1,77 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failure shared by repositories and services. Every error returned
+// by them matches exactly one of these through errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrPrecondition = errors.New("precondition failed")
+	ErrStorage      = errors.New("storage failure")
+)
+
+// Error is a classified domain error. Kind is one of the sentinels above and
+// Err, when set, is the underlying cause. Validation errors may list the
+// offending fields.
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError describes why a single field of a payload was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+func NotFound(format string, args ...any) *Error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...any) *Error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func Validation(format string, args ...any) *Error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+func PreconditionFailed(format string, args ...any) *Error {
+	return &Error{Kind: ErrPrecondition, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalid reports every rejected field of a payload at once.
+func Invalid(fields ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Message: "Request validation failed", Fields: fields}
+}
+
+// Storage classifies err as a storage failure. Nil and already classified
+// errors are returned unchanged.
+func Storage(err error) error {
+	var classified *Error
+	if err == nil || errors.As(err, &classified) {
+		return err
+	}
+	return &Error{Kind: ErrStorage, Message: "storage failure", Err: err}
+}
@@ -0,0 +1,37 @@
+package listing
+
+import (
+	"encoding/base64"
+	"strings"
+	"template-golang/internal/domain/errs"
+)
+
+// SortKey builds the key records sorted by value are ordered by. Values are
+// compared case-insensitively and the ID keeps keys of equal values unique.
+func SortKey(value, id string) []byte {
+	return append(SortPrefix(value), id...)
+}
+
+// SortPrefix returns the prefix shared by the sort keys of value.
+func SortPrefix(value string) []byte {
+	return []byte(strings.ToLower(value) + "\x00")
+}
+
+// EncodeCursor returns the opaque cursor pointing at the sort key of the
+// last record of a page listed in sort order.
+func EncodeCursor(sort string, key []byte) string {
+	return base64.RawURLEncoding.EncodeToString(append([]byte(sort+":"), key...))
+}
+
+// DecodeCursor returns the sort key encoded in cursor, rejecting cursors
+// issued for another sort order.
+func DecodeCursor(sort, cursor string) ([]byte, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), sort+":") {
+		return nil, errs.Validation("Invalid cursor %q", cursor)
+	}
+	return raw[len(sort)+1:], nil
+}
@@ -0,0 +1,22 @@
+package listing
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Query selects one page of a sorted collection. After is the opaque cursor
+// returned as NextCursor by the previous page.
+type Query struct {
+	Limit int
+	After string
+	Sort  string
+}
+
+// Page is the envelope returned by list endpoints. Total counts every record
+// matching the filters, not only the ones in Items.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
@@ -0,0 +1,56 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"template-golang/internal/domain/errs"
+	"unicode/utf8"
+)
+
+var (
+	// Identifier matches the IDs accepted for stored records.
+	Identifier = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// PersonName matches letters, spaces and the punctuation found in names.
+	PersonName = regexp.MustCompile(`^[\p{L}\p{M}][\p{L}\p{M} '.-]*$`)
+	// Title matches names of positions and departments.
+	Title = regexp.MustCompile(`^[\p{L}\p{M}\p{N}][\p{L}\p{M}\p{N} '.,&()/+-]*$`)
+)
+
+// Validator collects every violation of a payload so they can be reported at once.
+type Validator struct {
+	fields []errs.FieldError
+}
+
+func (v *Validator) Add(field, format string, args ...any) {
+	v.fields = append(v.fields, errs.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Required rejects empty or whitespace-only values and reports whether value is present.
+func (v *Validator) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *Validator) MaxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.Add(field, "must be at most %d characters", max)
+	}
+}
+
+func (v *Validator) Matches(field, value string, re *regexp.Regexp, description string) {
+	if value != "" && !re.MatchString(value) {
+		v.Add(field, "must contain only %s", description)
+	}
+}
+
+// Err returns the collected violations as a validation error, or nil.
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return errs.Invalid(v.fields...)
+}
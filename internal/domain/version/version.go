@@ -0,0 +1,26 @@
+package version
+
+import "slices"
+
+// Condition is a precondition on the stored version of a record, checked by
+// repositories inside their write transaction. The zero value holds for any
+// version.
+type Condition struct {
+	set      bool
+	versions []int64
+}
+
+// Any returns the condition holding for every version.
+func Any() Condition {
+	return Condition{}
+}
+
+// Matching returns the condition holding only for the given versions. Without
+// versions it never holds.
+func Matching(versions ...int64) Condition {
+	return Condition{set: true, versions: versions}
+}
+
+func (c Condition) Holds(v int64) bool {
+	return !c.set || slices.Contains(c.versions, v)
+}
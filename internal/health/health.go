@@ -0,0 +1,139 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"template-golang/internal/app/httperror"
+	"time"
+)
+
+// Version is the release of the service, set at build time with
+// -ldflags "-X template-golang/internal/health.Version=1.2.3".
+var Version = "dev"
+
+type Routes struct {
+	Healthz string
+	Readyz  string
+	Status  string
+}
+
+var Paths = Routes{
+	Healthz: "/healthz",
+	Readyz:  "/readyz",
+	Status:  "/status",
+}
+
+// Checker probes the storage backend.
+type Checker interface {
+	// Ready reports why the storage cannot serve requests, if it cannot.
+	Ready() error
+	Storage() (Storage, error)
+}
+
+// Storage describes the storage backend in /status.
+type Storage struct {
+	Backend   string         `json:"backend"`
+	Path      string         `json:"path,omitempty"`
+	SizeBytes int64          `json:"size_bytes"`
+	Keys      map[string]int `json:"keys"`
+}
+
+type Build struct {
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified"`
+}
+
+type Status struct {
+	Version       string    `json:"version"`
+	StartedAt     time.Time `json:"started_at"`
+	Uptime        string    `json:"uptime"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+	Build         Build     `json:"build"`
+	Storage       Storage   `json:"storage"`
+}
+
+type Handler struct {
+	checker Checker
+	started time.Time
+	build   Build
+}
+
+func NewHandler(checker Checker) *Handler {
+	return &Handler{checker: checker, started: time.Now(), build: readBuild()}
+}
+
+// @Summary Liveness probe
+// @Description reports that the process is alive
+// @Tags health
+// @Produce  json
+// @Success 200
+// @Router /healthz [get]
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// @Summary Readiness probe
+// @Description reports whether the storage can serve requests
+// @Tags health
+// @Produce  json
+// @Success 200
+// @Failure 503
+// @Router /readyz [get]
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if err := h.checker.Ready(); err != nil {
+		httperror.Status(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ready"})
+}
+
+// @Summary Service status
+// @Description reports version, uptime, build and storage details
+// @Tags health
+// @Produce  json
+// @Success 200 {object} Status
+// @Router /status [get]
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	storage, err := h.checker.Storage()
+	if err != nil {
+		httperror.Write(w, r, err)
+		return
+	}
+	uptime := time.Since(h.started)
+	writeJSON(w, Status{
+		Version:       Version,
+		StartedAt:     h.started.UTC(),
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: uptime.Seconds(),
+		Build:         h.build,
+		Storage:       storage,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func readBuild() Build {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Build{}
+	}
+	build := Build{GoVersion: info.GoVersion}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			build.Revision = s.Value
+		case "vcs.time":
+			build.Time = s.Value
+		case "vcs.modified":
+			build.Modified = s.Value == "true"
+		}
+	}
+	return build
+}
@@ -0,0 +1,90 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"template-golang/internal/app/httperror"
+	"template-golang/internal/health"
+	"testing"
+)
+
+// checker reports the configured readiness and storage.
+type checker struct {
+	ready      error
+	storage    health.Storage
+	storageErr error
+}
+
+func (c checker) Ready() error { return c.ready }
+
+func (c checker) Storage() (health.Storage, error) { return c.storage, c.storageErr }
+
+func get(handler http.HandlerFunc, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestHealthz(t *testing.T) {
+	h := health.NewHandler(checker{ready: errors.New("bucket Employees does not exist")})
+	w := get(h.Healthz, health.Paths.Healthz)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("status = %d, Cache-Control %q; a live process is healthy whatever its storage", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		ready      error
+		wantStatus int
+	}{
+		{"ready", nil, http.StatusOK},
+		{"missing bucket", errors.New("bucket Departments does not exist"), http.StatusServiceUnavailable},
+		{"closed database", errors.New("database not open"), http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(health.NewHandler(checker{ready: tt.ready}).Readyz, health.Paths.Readyz)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.ready == nil {
+				return
+			}
+			var p httperror.Problem
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Detail != tt.ready.Error() {
+				t.Errorf("detail = %q, want %q", p.Detail, tt.ready.Error())
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	storage := health.Storage{Backend: "bbolt", Path: "my.db", SizeBytes: 32768, Keys: map[string]int{"Employees": 12, "Departments": 3}}
+	w := get(health.NewHandler(checker{storage: storage}).Status, health.Paths.Status)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var s health.Status
+	if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Version != health.Version || s.StartedAt.IsZero() || s.UptimeSeconds < 0 || s.Build.GoVersion == "" {
+		t.Errorf("status = %+v", s)
+	}
+	got := s.Storage
+	if got.Backend != "bbolt" || got.Path != "my.db" || got.SizeBytes != 32768 || got.Keys["Employees"] != 12 || got.Keys["Departments"] != 3 {
+		t.Errorf("storage = %+v, want %+v", got, storage)
+	}
+
+	w = get(health.NewHandler(checker{storageErr: errors.New("disk gone")}).Status, health.Paths.Status)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status with a failing storage = %d, want 500", w.Code)
+	}
+}
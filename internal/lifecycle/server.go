@@ -0,0 +1,85 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Server runs an HTTP server until the process is asked to stop, then drains
+// in-flight requests and closes the resources the handlers depend on, such
+// as the bbolt database.
+type Server struct {
+	srv             *http.Server
+	shutdownTimeout time.Duration
+	closers         []io.Closer
+}
+
+// New wraps srv. closers are closed in order once srv has stopped serving.
+func New(srv *http.Server, shutdownTimeout time.Duration, closers ...io.Closer) *Server {
+	return &Server{srv: srv, shutdownTimeout: shutdownTimeout, closers: closers}
+}
+
+// Run listens on the server address and serves until ctx is cancelled or the
+// process receives SIGINT or SIGTERM.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return errors.Join(err, s.close())
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is Run on an existing listener. Once a stop is requested, new
+// connections are refused and in-flight requests get the shutdown timeout
+// to complete before their connections are closed.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("http server listening", "addr", ln.Addr().String())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.srv.Serve(ln)
+	}()
+
+	var err error
+	select {
+	case err = <-served:
+		// The server failed on its own; nothing is left to drain.
+	case <-ctx.Done():
+		slog.Info("shutting down http server", "timeout", s.shutdownTimeout)
+		err = s.shutdown()
+		<-served
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return errors.Join(err, s.close())
+}
+
+func (s *Server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.srv.Shutdown(ctx); err != nil {
+		slog.Warn("shutdown deadline exceeded, closing open connections")
+		// Drop the connections still open past the deadline.
+		return errors.Join(err, s.srv.Close())
+	}
+	return nil
+}
+
+func (s *Server) close() error {
+	var errs []error
+	for _, c := range s.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
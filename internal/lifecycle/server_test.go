@@ -0,0 +1,182 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// recorder keeps the order of the events observed during a shutdown.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// start serves handler on a loopback port and returns its URL and the
+// channel receiving the result of Serve.
+func start(t *testing.T, ctx context.Context, s *Server) (string, <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, ln) }()
+	return "http://" + ln.Addr().String(), done
+}
+
+func wait(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+		return nil
+	}
+}
+
+func TestServeDrainsInFlightRequestsBeforeClosing(t *testing.T) {
+	events := &recorder{}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		events.add("request finished")
+	})
+	db := closerFunc(func() error {
+		events.add("db closed")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := start(t, ctx, New(&http.Server{Handler: handler}, 5*time.Second, db))
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	<-entered
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if got := events.list(); len(got) != 0 {
+		t.Fatalf("events before the request finished = %v", got)
+	}
+	close(release)
+
+	if err := wait(t, done); err != nil {
+		t.Fatalf("Serve() = %v", err)
+	}
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("in-flight request status = %d, want %d", code, http.StatusOK)
+	}
+	want := []string{"request finished", "db closed"}
+	if got := events.list(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestServeClosesResourcesWhenDrainDeadlineExpires(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+	closed := make(chan struct{})
+	db := closerFunc(func() error {
+		close(closed)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := start(t, ctx, New(&http.Server{Handler: handler}, 50*time.Millisecond, db))
+	go func() {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-entered
+	cancel()
+	if err := wait(t, done); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Serve() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case <-closed:
+	default:
+		t.Error("database was not closed after the drain deadline")
+	}
+}
+
+func TestServeStopsOnSIGTERM(t *testing.T) {
+	closed := make(chan struct{})
+	db := closerFunc(func() error {
+		close(closed)
+		return nil
+	})
+	url, done := start(t, context.Background(), New(&http.Server{Handler: http.NotFoundHandler()}, time.Second, db))
+
+	// Make sure the server is up, and the signal handler installed, first.
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(t, done); err != nil {
+		t.Fatalf("Serve() = %v", err)
+	}
+	<-closed
+}
+
+func TestRunClosesResourcesWhenListenFails(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	closeErr := errors.New("close failed")
+	var db io.Closer = closerFunc(func() error { return closeErr })
+	s := New(&http.Server{Addr: ln.Addr().String()}, time.Second, db)
+
+	err = s.Run(context.Background())
+	if err == nil || !errors.Is(err, closeErr) {
+		t.Errorf("Run() = %v, want the listen error joined with %v", err, closeErr)
+	}
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/oklog/ulid/v2"
+	"log/slog"
+	"net/http"
+	"template-golang/internal/app/httpx"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// Handler serves router, assigning every request an ID, taken from the
+// X-Request-ID header when the client sent a usable one, and a logger
+// carrying it. Each request is logged once it has been served.
+func Handler(base *slog.Logger, router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = ulid.Make().String()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		logger := base.With(slog.String("request_id", id))
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		r = r.WithContext(WithLogger(ctx, logger))
+
+		rec := httpx.NewRecorder(w)
+		start := time.Now()
+		router.ServeHTTP(rec, r)
+
+		level := slog.LevelInfo
+		if rec.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("route", httpx.Route(router, r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.Status),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("bytes", rec.Bytes),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"template-golang/internal/app/httperror"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/logging"
+	"testing"
+)
+
+// serve sends a request carrying requestID to a router logging as JSON to
+// the returned buffer.
+func serve(t *testing.T, method, target, requestID string) (*httptest.ResponseRecorder, *bytes.Buffer) {
+	t.Helper()
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	r := mux.NewRouter()
+	r.HandleFunc("/employees/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("looking up", "id", mux.Vars(r)["id"])
+		if mux.Vars(r)["id"] == "missing" {
+			httperror.Write(w, r, errs.NotFound("Employee missing not found"))
+			return
+		}
+		w.Write([]byte(`{"id":"e1"}`))
+	}).Methods("GET")
+	r.HandleFunc("/boom", func(w http.ResponseWriter, r *http.Request) {
+		httperror.Write(w, r, errors.New("boom"))
+	})
+
+	req := httptest.NewRequest(method, target, nil)
+	if requestID != "" {
+		req.Header.Set(logging.RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	logging.Handler(logger, r).ServeHTTP(w, req)
+	return w, &logs
+}
+
+// records decodes every JSON log record written to logs.
+func records(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	dec := json.NewDecoder(logs)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestHandlerRequestIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{"propagated", "client-4f2a", true},
+		{"missing", "", false},
+		{"with spaces", "client 4f2a", false},
+		{"oversized", strings.Repeat("a", 129), false},
+		{"at the size limit", strings.Repeat("a", 128), true},
+		{"not ASCII", "clïent", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, logs := serve(t, http.MethodGet, "/employees/missing", tt.incoming)
+			id := w.Header().Get(logging.RequestIDHeader)
+			if tt.kept && id != tt.incoming {
+				t.Errorf("request ID = %q, want the incoming %q", id, tt.incoming)
+			}
+			if !tt.kept && (id == "" || id == tt.incoming) {
+				t.Errorf("request ID = %q, want a generated one", id)
+			}
+
+			var p httperror.Problem
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			if p.RequestID != id {
+				t.Errorf("problem request_id = %q, want %q", p.RequestID, id)
+			}
+			for _, record := range records(t, logs) {
+				if record["request_id"] != id {
+					t.Errorf("log record %v lacks request_id %q", record, id)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerLogsEachRequest(t *testing.T) {
+	tests := []struct {
+		method     string
+		target     string
+		wantRoute  string
+		wantStatus float64
+		wantLevel  string
+		wantBytes  bool
+	}{
+		{http.MethodGet, "/employees/e1", "/employees/{id}", http.StatusOK, "INFO", true},
+		{http.MethodGet, "/nowhere", "unmatched", http.StatusNotFound, "INFO", true},
+		{http.MethodPost, "/employees/e1", "unmatched", http.StatusMethodNotAllowed, "INFO", false},
+		{http.MethodGet, "/boom", "/boom", http.StatusInternalServerError, "ERROR", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w, logs := serve(t, tt.method, tt.target, "req-1")
+			all := records(t, logs)
+			if len(all) == 0 {
+				t.Fatal("nothing was logged")
+			}
+			record := all[len(all)-1]
+			if record["msg"] != "request" || record["level"] != tt.wantLevel {
+				t.Errorf("last record = %v, want a request record at %s", record, tt.wantLevel)
+			}
+			if record["method"] != tt.method || record["route"] != tt.wantRoute || record["path"] != tt.target || record["status"] != tt.wantStatus {
+				t.Errorf("record = %v, want %s %s routed to %s with status %v", record, tt.method, tt.target, tt.wantRoute, tt.wantStatus)
+			}
+			if _, ok := record["duration"].(float64); !ok {
+				t.Errorf("record lacks a duration: %v", record)
+			}
+			if bytes, _ := record["bytes"].(float64); int(bytes) != w.Body.Len() || (bytes > 0) != tt.wantBytes {
+				t.Errorf("record bytes = %v, response has %d", record["bytes"], w.Body.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.etcd.io/bbolt"
+)
+
+// BoltCollector exports the counters of db.Stats().
+type BoltCollector struct {
+	db *bbolt.DB
+
+	readTx     *prometheus.Desc
+	openReadTx *prometheus.Desc
+	freePages  *prometheus.Desc
+	pendPages  *prometheus.Desc
+	freeAlloc  *prometheus.Desc
+	freelist   *prometheus.Desc
+	writes     *prometheus.Desc
+	writeTime  *prometheus.Desc
+	pageAlloc  *prometheus.Desc
+	cursors    *prometheus.Desc
+	rebalances *prometheus.Desc
+	splits     *prometheus.Desc
+	spills     *prometheus.Desc
+}
+
+func NewBoltCollector(db *bbolt.DB) *BoltCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("bbolt_"+name, help, nil, nil)
+	}
+	return &BoltCollector{
+		db:         db,
+		readTx:     desc("read_tx_total", "Read transactions started."),
+		openReadTx: desc("open_read_tx", "Read transactions currently open."),
+		freePages:  desc("free_pages", "Pages on the freelist."),
+		pendPages:  desc("pending_pages", "Pages pending release on the freelist."),
+		freeAlloc:  desc("free_alloc_bytes", "Bytes allocated in free pages."),
+		freelist:   desc("freelist_inuse_bytes", "Bytes used by the freelist."),
+		writes:     desc("writes_total", "Page writes performed by committed transactions."),
+		writeTime:  desc("write_seconds_total", "Time spent writing pages to disk."),
+		pageAlloc:  desc("page_alloc_bytes_total", "Bytes allocated for pages."),
+		cursors:    desc("cursors_total", "Cursors created."),
+		rebalances: desc("rebalances_total", "Node rebalances."),
+		splits:     desc("splits_total", "Node splits."),
+		spills:     desc("spills_total", "Node spills."),
+	}
+}
+
+func (c *BoltCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *BoltCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.db.Stats()
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter(c.readTx, float64(s.TxN))
+	gauge(c.openReadTx, float64(s.OpenTxN))
+	gauge(c.freePages, float64(s.FreePageN))
+	gauge(c.pendPages, float64(s.PendingPageN))
+	gauge(c.freeAlloc, float64(s.FreeAlloc))
+	gauge(c.freelist, float64(s.FreelistInuse))
+	counter(c.writes, float64(s.TxStats.GetWrite()))
+	counter(c.writeTime, s.TxStats.GetWriteTime().Seconds())
+	counter(c.pageAlloc, float64(s.TxStats.GetPageAlloc()))
+	counter(c.cursors, float64(s.TxStats.GetCursorCount()))
+	counter(c.rebalances, float64(s.TxStats.GetRebalance()))
+	counter(c.splits, float64(s.TxStats.GetSplit()))
+	counter(c.spills, float64(s.TxStats.GetSpill()))
+}
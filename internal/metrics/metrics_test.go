@@ -0,0 +1,77 @@
+package metrics_test
+
+import (
+	"errors"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"template-golang/internal/metrics"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+	body, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestInstrumentLabelsRequestsByRoute(t *testing.T) {
+	m := metrics.New()
+	r := mux.NewRouter()
+	r.HandleFunc("/employees/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}).Methods("GET")
+	h := m.Instrument(r, r)
+
+	for _, target := range []string{"/employees/e1", "/employees/e2", "/employees/missing", "/nowhere"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/employees/e1", nil))
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`http_requests_total{code="200",method="GET",route="/employees/{id}"} 2`,
+		`http_requests_total{code="404",method="GET",route="/employees/{id}"} 1`,
+		// Requests no route matches are counted under a single label
+		`http_requests_total{code="404",method="GET",route="unmatched"} 1`,
+		`http_requests_total{code="405",method="DELETE",route="unmatched"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/employees/{id}"} 3`,
+		`http_request_duration_seconds_count{method="GET",route="unmatched"} 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	if strings.Contains(out, `route="/employees/e1"`) {
+		t.Error("metrics are labelled with a raw path")
+	}
+}
+
+func TestObserveRepository(t *testing.T) {
+	m := metrics.New()
+	observe := m.ObserveRepository("employee")
+	observe("get", time.Millisecond, nil)
+	observe("get", time.Millisecond, errors.New("not found"))
+	observe("create", time.Millisecond, nil)
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`repository_operation_duration_seconds_count{operation="get",repository="employee",result="ok"} 1`,
+		`repository_operation_duration_seconds_count{operation="get",repository="employee",result="error"} 1`,
+		`repository_operation_duration_seconds_count{operation="create",repository="employee",result="ok"} 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
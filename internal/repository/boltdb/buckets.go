@@ -0,0 +1,36 @@
+package boltdb
+
+import "go.etcd.io/bbolt"
+
+// Bucket names shared by the bbolt repositories.
+const (
+	EmployeeBucket        = "Employees"
+	DepartmentBucket      = "Departments"
+	DepartmentIndexBucket = "EmployeesByDepartment"
+	ManagerIndexBucket    = "EmployeesByManager"
+	ParentIndexBucket     = "DepartmentsByParent"
+	EmailIndexBucket      = "EmployeesByEmail"
+
+	EmployeeNameIndexBucket     = "EmployeesByName"
+	EmployeePositionIndexBucket = "EmployeesByPosition"
+	DepartmentNameIndexBucket   = "DepartmentsByName"
+)
+
+var indexBuckets = []string{
+	DepartmentIndexBucket,
+	ManagerIndexBucket,
+	ParentIndexBucket,
+	EmailIndexBucket,
+	EmployeeNameIndexBucket,
+	EmployeePositionIndexBucket,
+	DepartmentNameIndexBucket,
+}
+
+// DepartmentExists reports whether id is stored in the Departments bucket.
+func DepartmentExists(tx *bbolt.Tx, id string) bool {
+	if id == "" {
+		return false
+	}
+	b := tx.Bucket([]byte(DepartmentBucket))
+	return b != nil && b.Get([]byte(id)) != nil
+}
@@ -0,0 +1,25 @@
+package boltdb
+
+import (
+	"context"
+	"go.etcd.io/bbolt"
+)
+
+// View runs fn in a read-only transaction unless ctx is already done. bbolt
+// knows nothing of contexts, so functions iterating over many records check
+// ctx themselves.
+func View(ctx context.Context, db *bbolt.DB, fn func(*bbolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return db.View(fn)
+}
+
+// Update runs fn in a read-write transaction unless ctx is already done. The
+// transaction is rolled back if fn returns an error, including ctx.Err().
+func Update(ctx context.Context, db *bbolt.DB, fn func(*bbolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return db.Update(fn)
+}
@@ -0,0 +1,62 @@
+package boltdb
+
+import (
+	"fmt"
+	"go.etcd.io/bbolt"
+	"slices"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/health"
+)
+
+var recordBuckets = []string{EmployeeBucket, DepartmentBucket}
+
+// EnsureBuckets creates the record buckets of a new database.
+func EnsureBuckets(db *bbolt.DB) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range recordBuckets {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Health probes a bbolt database for the health endpoints.
+type Health struct {
+	db *bbolt.DB
+}
+
+func NewHealth(db *bbolt.DB) *Health {
+	return &Health{db: db}
+}
+
+// Ready checks that a read transaction succeeds and sees every bucket.
+func (h *Health) Ready() error {
+	return h.db.View(func(tx *bbolt.Tx) error {
+		for _, name := range slices.Concat(recordBuckets, indexBuckets) {
+			if tx.Bucket([]byte(name)) == nil {
+				return fmt.Errorf("bucket %s does not exist", name)
+			}
+		}
+		return nil
+	})
+}
+
+func (h *Health) Storage() (health.Storage, error) {
+	storage := health.Storage{
+		Backend: "bbolt",
+		Path:    h.db.Path(),
+		Keys:    map[string]int{},
+	}
+	err := h.db.View(func(tx *bbolt.Tx) error {
+		storage.SizeBytes = tx.Size()
+		for _, name := range recordBuckets {
+			if b := tx.Bucket([]byte(name)); b != nil {
+				storage.Keys[name] = b.Stats().KeyN
+			}
+		}
+		return nil
+	})
+	return storage, errs.Storage(err)
+}
@@ -0,0 +1,86 @@
+package boltdb_test
+
+import (
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"strings"
+	"template-golang/internal/repository/boltdb"
+	"testing"
+)
+
+func openDB(t *testing.T) *bbolt.DB {
+	t.Helper()
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := boltdb.EnsureBuckets(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestHealthReady(t *testing.T) {
+	db := openDB(t)
+	if err := boltdb.EnsureIndexes(db); err != nil {
+		t.Fatal(err)
+	}
+	h := boltdb.NewHealth(db)
+	if err := h.Ready(); err != nil {
+		t.Fatalf("ready: %v", err)
+	}
+
+	err := db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket([]byte(boltdb.DepartmentBucket))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Ready(); err == nil || !strings.Contains(err.Error(), boltdb.DepartmentBucket) {
+		t.Errorf("ready without the %s bucket: %v", boltdb.DepartmentBucket, err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Ready(); err == nil {
+		t.Error("closed database is ready")
+	}
+}
+
+func TestHealthStorage(t *testing.T) {
+	db := openDB(t)
+	err := db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket([]byte(boltdb.DepartmentBucket)).Put([]byte("eng"), []byte(`{"id":"eng","name":"Engineering"}`)); err != nil {
+			return err
+		}
+		for _, id := range []string{"e1", "e2", "e3"} {
+			if err := tx.Bucket([]byte(boltdb.EmployeeBucket)).Put([]byte(id), []byte(`{"id":"`+id+`"}`)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := boltdb.NewHealth(db).Storage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Backend != "bbolt" || s.Path != db.Path() {
+		t.Errorf("storage = %+v", s)
+	}
+	if s.Keys[boltdb.EmployeeBucket] != 3 || s.Keys[boltdb.DepartmentBucket] != 1 || len(s.Keys) != 2 {
+		t.Errorf("keys = %v, want 3 employees and 1 department", s.Keys)
+	}
+	info, err := os.Stat(db.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SizeBytes <= 0 || s.SizeBytes > info.Size() {
+		t.Errorf("size = %d bytes, file has %d", s.SizeBytes, info.Size())
+	}
+}
@@ -0,0 +1,277 @@
+package boltdb
+
+import (
+	"go.etcd.io/bbolt"
+	"log/slog"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/listing"
+)
+
+// The department index is a bucket holding one nested bucket per department
+// ID, whose keys are the IDs of the employees assigned to that department.
+// The manager and parent indexes are laid out the same way, keyed by the
+// manager ID and the parent department ID.
+//
+// The email index maps every email in use to the ID of its employee, and
+// holds at most one employee per email.
+//
+// Sort indexes map listing.SortKey(value, id) to the record ID so records can
+// be walked in value order with a cursor.
+//
+// Every index must be written in the same transaction as the record it
+// derives from.
+
+// IndexEmployee adds e to every employee index.
+func IndexEmployee(tx *bbolt.Tx, e employee.Employee) error {
+	if err := indexUnder(tx, DepartmentIndexBucket, e.DepartmentId, e.ID); err != nil {
+		return err
+	}
+	if err := indexUnder(tx, ManagerIndexBucket, e.ManagerID, e.ID); err != nil {
+		return err
+	}
+	if err := putUnique(tx, EmailIndexBucket, e.Email, e.ID); err != nil {
+		return err
+	}
+	if err := putSortKey(tx, EmployeeNameIndexBucket, e.Name, e.ID); err != nil {
+		return err
+	}
+	return putSortKey(tx, EmployeePositionIndexBucket, e.Position, e.ID)
+}
+
+// UnindexEmployee removes e from every employee index.
+func UnindexEmployee(tx *bbolt.Tx, e employee.Employee) error {
+	if err := unindexUnder(tx, DepartmentIndexBucket, e.DepartmentId, e.ID); err != nil {
+		return err
+	}
+	if err := unindexUnder(tx, ManagerIndexBucket, e.ManagerID, e.ID); err != nil {
+		return err
+	}
+	if err := deleteUnique(tx, EmailIndexBucket, e.Email, e.ID); err != nil {
+		return err
+	}
+	if err := deleteSortKey(tx, EmployeeNameIndexBucket, e.Name, e.ID); err != nil {
+		return err
+	}
+	return deleteSortKey(tx, EmployeePositionIndexBucket, e.Position, e.ID)
+}
+
+// IndexDepartment adds d to every department index.
+func IndexDepartment(tx *bbolt.Tx, d department.Department) error {
+	if err := indexUnder(tx, ParentIndexBucket, d.ParentID, d.ID); err != nil {
+		return err
+	}
+	return putSortKey(tx, DepartmentNameIndexBucket, d.Name, d.ID)
+}
+
+// UnindexDepartment removes d from every department index.
+func UnindexDepartment(tx *bbolt.Tx, d department.Department) error {
+	if err := unindexUnder(tx, ParentIndexBucket, d.ParentID, d.ID); err != nil {
+		return err
+	}
+	return deleteSortKey(tx, DepartmentNameIndexBucket, d.Name, d.ID)
+}
+
+// EmployeeIDsByDepartment returns the IDs indexed under deptID.
+func EmployeeIDsByDepartment(tx *bbolt.Tx, deptID string) []string {
+	return idsUnder(tx, DepartmentIndexBucket, deptID)
+}
+
+// EmployeeIDsByManager returns the IDs of the direct reports of managerID.
+func EmployeeIDsByManager(tx *bbolt.Tx, managerID string) []string {
+	return idsUnder(tx, ManagerIndexBucket, managerID)
+}
+
+// EmployeeIDByEmail returns the ID of the employee using email, empty when
+// no employee does.
+func EmployeeIDByEmail(tx *bbolt.Tx, email string) string {
+	index := tx.Bucket([]byte(EmailIndexBucket))
+	if index == nil || email == "" {
+		return ""
+	}
+	return string(index.Get([]byte(email)))
+}
+
+// DepartmentIDsByParent returns the IDs of the departments directly under
+// parentID.
+func DepartmentIDsByParent(tx *bbolt.Tx, parentID string) []string {
+	return idsUnder(tx, ParentIndexBucket, parentID)
+}
+
+// DepartmentSubtree returns id followed by the IDs of every department below
+// it, level by level.
+func DepartmentSubtree(tx *bbolt.Tx, id string) []string {
+	ids := []string{id}
+	seen := map[string]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range DepartmentIDsByParent(tx, ids[i]) {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+func idsUnder(tx *bbolt.Tx, bucket, key string) []string {
+	index := tx.Bucket([]byte(bucket))
+	if index == nil {
+		return nil
+	}
+	nested := index.Bucket([]byte(key))
+	if nested == nil {
+		return nil
+	}
+	var ids []string
+	_ = nested.ForEach(func(k, _ []byte) error {
+		ids = append(ids, string(k))
+		return nil
+	})
+	return ids
+}
+
+// HasIndexes reports whether every index bucket has been created.
+func HasIndexes(tx *bbolt.Tx) bool {
+	for _, name := range indexBuckets {
+		if tx.Bucket([]byte(name)) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// RebuildIndexes drops every index and regenerates them from the records
+// stored in the Employees and Departments buckets.
+func RebuildIndexes(tx *bbolt.Tx) error {
+	for _, name := range indexBuckets {
+		if tx.Bucket([]byte(name)) != nil {
+			if err := tx.DeleteBucket([]byte(name)); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.CreateBucket([]byte(name)); err != nil {
+			return err
+		}
+	}
+	if b := tx.Bucket([]byte(EmployeeBucket)); b != nil {
+		err := b.ForEach(func(k, v []byte) error {
+			emp, err := DecodeEmployee(v)
+			if err != nil {
+				return err
+			}
+			emp.ID = string(k)
+			return IndexEmployee(tx, emp)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if b := tx.Bucket([]byte(DepartmentBucket)); b != nil {
+		return b.ForEach(func(k, v []byte) error {
+			dept, err := DecodeDepartment(v)
+			if err != nil {
+				return err
+			}
+			dept.ID = string(k)
+			return IndexDepartment(tx, dept)
+		})
+	}
+	return nil
+}
+
+// EnsureIndexes builds the indexes of databases written before they existed.
+func EnsureIndexes(db *bbolt.DB) error {
+	var exists bool
+	if err := db.View(func(tx *bbolt.Tx) error {
+		exists = HasIndexes(tx)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	slog.Info("rebuilding bbolt indexes", "path", db.Path())
+	return db.Update(RebuildIndexes)
+}
+
+// indexUnder adds id to the nested bucket key of the index bucket.
+func indexUnder(tx *bbolt.Tx, bucket, key, id string) error {
+	if key == "" {
+		return nil
+	}
+	index, err := tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+	nested, err := index.CreateBucketIfNotExists([]byte(key))
+	if err != nil {
+		return err
+	}
+	return nested.Put([]byte(id), []byte{})
+}
+
+// unindexUnder removes id from the nested bucket key, dropping the nested
+// bucket once it is empty.
+func unindexUnder(tx *bbolt.Tx, bucket, key, id string) error {
+	if key == "" {
+		return nil
+	}
+	index := tx.Bucket([]byte(bucket))
+	if index == nil {
+		return nil
+	}
+	nested := index.Bucket([]byte(key))
+	if nested == nil {
+		return nil
+	}
+	if err := nested.Delete([]byte(id)); err != nil {
+		return err
+	}
+	if k, _ := nested.Cursor().First(); k == nil {
+		return index.DeleteBucket([]byte(key))
+	}
+	return nil
+}
+
+// putUnique maps key to id in the index bucket. Callers check beforehand
+// that key is not used by another record.
+func putUnique(tx *bbolt.Tx, bucket, key, id string) error {
+	if key == "" {
+		return nil
+	}
+	b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), []byte(id))
+}
+
+// deleteUnique removes key from the index bucket when it maps to id.
+func deleteUnique(tx *bbolt.Tx, bucket, key, id string) error {
+	if key == "" {
+		return nil
+	}
+	b := tx.Bucket([]byte(bucket))
+	if b == nil || string(b.Get([]byte(key))) != id {
+		return nil
+	}
+	return b.Delete([]byte(key))
+}
+
+func putSortKey(tx *bbolt.Tx, bucket, value, id string) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+	return b.Put(listing.SortKey(value, id), []byte(id))
+}
+
+func deleteSortKey(tx *bbolt.Tx, bucket, value, id string) error {
+	b := tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	return b.Delete(listing.SortKey(value, id))
+}
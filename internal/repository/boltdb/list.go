@@ -0,0 +1,67 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"go.etcd.io/bbolt"
+	"template-golang/internal/domain/listing"
+)
+
+// Range describes the walk performed by List. Records are visited in the key
+// order of Index, or of the records bucket itself when Index is empty, and
+// only keys starting with Prefix are considered.
+type Range struct {
+	Index  string
+	Prefix []byte
+	After  []byte
+	Limit  int
+}
+
+// List walks the records of bucket over r with a bbolt cursor, returning the
+// page following r.After. Every record accepted by match counts towards the
+// page total. Records are read with decode. The walk stops with ctx.Err()
+// once ctx is done.
+func List[T any](ctx context.Context, tx *bbolt.Tx, bucket string, r Range, sort string, decode func([]byte) (T, error), match func(T) bool) (listing.Page[T], error) {
+	page := listing.Page[T]{Items: []T{}}
+	records := tx.Bucket([]byte(bucket))
+	if records == nil {
+		return page, nil
+	}
+	keys := records
+	if r.Index != "" {
+		if keys = tx.Bucket([]byte(r.Index)); keys == nil {
+			return page, nil
+		}
+	}
+
+	var last []byte
+	c := keys.Cursor()
+	for k, v := c.Seek(r.Prefix); k != nil && bytes.HasPrefix(k, r.Prefix); k, v = c.Next() {
+		if err := ctx.Err(); err != nil {
+			return page, err
+		}
+		if r.Index != "" {
+			if v = records.Get(v); v == nil {
+				continue
+			}
+		}
+		item, err := decode(v)
+		if err != nil {
+			return page, err
+		}
+		if match != nil && !match(item) {
+			continue
+		}
+		page.Total++
+		if r.After != nil && bytes.Compare(k, r.After) <= 0 {
+			continue
+		}
+		if len(page.Items) < r.Limit {
+			page.Items = append(page.Items, item)
+			last = append(last[:0], k...)
+		} else if page.NextCursor == "" {
+			page.NextCursor = listing.EncodeCursor(sort, last)
+		}
+	}
+	return page, nil
+}
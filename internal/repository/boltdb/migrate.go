@@ -0,0 +1,205 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"log/slog"
+	"strconv"
+)
+
+// MetaBucket holds the metadata of the database, such as SchemaVersionKey.
+const MetaBucket = "Meta"
+
+// SchemaVersionKey stores the version of the last migration applied, in
+// decimal. Databases without it are at version 1.
+const SchemaVersionKey = "schema_version"
+
+// DefaultMigrationBatch is the number of records rewritten per transaction
+// when migrating.
+const DefaultMigrationBatch = 1000
+
+// Migration upgrades a database from the previous schema version.
+type Migration struct {
+	Version int
+	Name    string
+	// Rewrites upgrade the records of their bucket one batch at a time.
+	Rewrites []Rewrite
+}
+
+// Rewrite upgrades the values of one bucket. Upgrade returns the new value,
+// or nil when value is already upgraded, so an interrupted migration can be
+// run again.
+type Rewrite struct {
+	Bucket  string
+	Upgrade func(key, value []byte) ([]byte, error)
+}
+
+// Migrations are the schema changes in version order. The version of the
+// last one is the schema version written by this build.
+var Migrations = []Migration{
+	{
+		Version: 2,
+		Name:    "record envelope",
+		Rewrites: []Rewrite{
+			{Bucket: EmployeeBucket, Upgrade: upgradeEmployeeV1},
+			{Bucket: DepartmentBucket, Upgrade: upgradeDepartmentV1},
+		},
+	},
+}
+
+// SchemaVersion returns the schema version of the database.
+func SchemaVersion(tx *bbolt.Tx) (int, error) {
+	b := tx.Bucket([]byte(MetaBucket))
+	if b == nil {
+		return 1, nil
+	}
+	v := b.Get([]byte(SchemaVersionKey))
+	if v == nil {
+		return 1, nil
+	}
+	return strconv.Atoi(string(v))
+}
+
+// Migrate applies the pending migrations. Records are rewritten in
+// transactions of batchSize records, and the schema version is recorded
+// once every record of a migration has been rewritten, so a migration
+// interrupted by a crash resumes from the start of its buckets. Opening a
+// database written by a newer build fails.
+func Migrate(db *bbolt.DB, batchSize int) error {
+	var current int
+	if err := db.View(func(tx *bbolt.Tx) (err error) {
+		current, err = SchemaVersion(tx)
+		return err
+	}); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	latest := Migrations[len(Migrations)-1].Version
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than the %d supported by this build", current, latest)
+	}
+
+	for _, m := range Migrations {
+		if m.Version <= current {
+			continue
+		}
+		slog.Info("migrating bbolt database", "path", db.Path(), "version", m.Version, "name", m.Name)
+		for _, rw := range m.Rewrites {
+			n, err := rw.run(db, batchSize)
+			if err != nil {
+				return fmt.Errorf("migration %d (%s), bucket %s: %w", m.Version, m.Name, rw.Bucket, err)
+			}
+			slog.Info("rewrote bbolt records", "bucket", rw.Bucket, "records", n)
+		}
+		if err := db.Update(func(tx *bbolt.Tx) error {
+			return setSchemaVersion(tx, m.Version)
+		}); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// run walks the bucket in key order, upgrading up to batchSize values per
+// transaction, and returns the number of values rewritten.
+func (rw Rewrite) run(db *bbolt.DB, batchSize int) (int, error) {
+	var after []byte
+	rewritten := 0
+	for done := false; !done; {
+		err := db.Update(func(tx *bbolt.Tx) error {
+			b := tx.Bucket([]byte(rw.Bucket))
+			if b == nil {
+				done = true
+				return nil
+			}
+
+			// Upgrades are collected first since writing through the bucket
+			// would invalidate the cursor.
+			type upgrade struct{ key, value []byte }
+			var batch []upgrade
+			c := b.Cursor()
+			k, v := c.First()
+			if after != nil {
+				if k, v = c.Seek(after); k != nil && bytes.Equal(k, after) {
+					k, v = c.Next()
+				}
+			}
+			for ; k != nil && len(batch) < batchSize; k, v = c.Next() {
+				after = append(after[:0], k...)
+				if v == nil {
+					continue // nested bucket
+				}
+				upgraded, err := rw.Upgrade(k, v)
+				if err != nil {
+					return fmt.Errorf("record %s: %w", k, err)
+				}
+				if upgraded != nil {
+					batch = append(batch, upgrade{append([]byte(nil), k...), upgraded})
+				}
+			}
+			done = k == nil
+
+			for _, u := range batch {
+				if err := b.Put(u.key, u.value); err != nil {
+					return err
+				}
+			}
+			rewritten += len(batch)
+			return nil
+		})
+		if err != nil {
+			return rewritten, err
+		}
+	}
+	return rewritten, nil
+}
+
+func setSchemaVersion(tx *bbolt.Tx, version int) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(MetaBucket))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(SchemaVersionKey), []byte(strconv.Itoa(version)))
+}
+
+// employeeV1 is the bare JSON of employees before version 2, which stored
+// the department under the API name "department:id".
+type employeeV1 struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Position     string `json:"position"`
+	DepartmentID string `json:"department:id"`
+	Version      int64  `json:"version"`
+}
+
+func upgradeEmployeeV1(key, value []byte) ([]byte, error) {
+	v, _, err := recordVersion(value)
+	if err != nil || v != 1 {
+		return nil, err
+	}
+	var old employeeV1
+	if err := json.Unmarshal(value, &old); err != nil {
+		return nil, err
+	}
+	return encodeAs(2, employeeRecord{
+		ID:           string(key),
+		Name:         old.Name,
+		Position:     old.Position,
+		DepartmentID: old.DepartmentID,
+		Version:      old.Version,
+	})
+}
+
+func upgradeDepartmentV1(key, value []byte) ([]byte, error) {
+	v, _, err := recordVersion(value)
+	if err != nil || v != 1 {
+		return nil, err
+	}
+	var old departmentRecord
+	if err := json.Unmarshal(value, &old); err != nil {
+		return nil, err
+	}
+	old.ID = string(key)
+	return encodeAs(2, old)
+}
@@ -0,0 +1,136 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"go.etcd.io/bbolt"
+	"strconv"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/repository/boltdb"
+	"testing"
+)
+
+// putLegacy writes records as bare JSON, the way they were stored before
+// the envelope.
+func putLegacy(t *testing.T, db *bbolt.DB, employees int) {
+	t.Helper()
+	err := db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket([]byte(boltdb.DepartmentBucket)).Put([]byte("eng"), []byte(`{"id":"eng","name":"Engineering","version":3}`)); err != nil {
+			return err
+		}
+		for i := 0; i < employees; i++ {
+			id := fmt.Sprintf("e%02d", i)
+			v := fmt.Sprintf(`{"id":%q,"name":"Ada","position":"Dev","department:id":"eng","version":2}`, id)
+			if err := tx.Bucket([]byte(boltdb.EmployeeBucket)).Put([]byte(id), []byte(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateUpgradesLegacyRecords(t *testing.T) {
+	db := openDB(t)
+	putLegacy(t, db, 7)
+
+	if err := boltdb.Migrate(db, 3); err != nil {
+		t.Fatal(err)
+	}
+	err := db.View(func(tx *bbolt.Tx) error {
+		v, err := boltdb.SchemaVersion(tx)
+		if err != nil {
+			return err
+		}
+		if latest := boltdb.Migrations[len(boltdb.Migrations)-1].Version; v != latest {
+			t.Errorf("schema version = %d, want %d", v, latest)
+		}
+
+		raw := tx.Bucket([]byte(boltdb.EmployeeBucket)).Get([]byte("e05"))
+		want := `{"v":2,"data":{"id":"e05","name":"Ada","position":"Dev","department_id":"eng","version":2}}`
+		if string(raw) != want {
+			t.Errorf("stored employee = %s, want %s", raw, want)
+		}
+		emp, err := boltdb.DecodeEmployee(raw)
+		if err != nil || emp.DepartmentId != "eng" || emp.Version != 2 {
+			t.Errorf("decoded employee = %+v, %v", emp, err)
+		}
+		dept, err := boltdb.DecodeDepartment(tx.Bucket([]byte(boltdb.DepartmentBucket)).Get([]byte("eng")))
+		if err != nil || dept.Name != "Engineering" || dept.Version != 3 {
+			t.Errorf("decoded department = %+v, %v", dept, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateResumesInterruptedMigration(t *testing.T) {
+	db := openDB(t)
+	putLegacy(t, db, 4)
+	// A crash after the first batch leaves upgraded and legacy records side
+	// by side, with the schema version unchanged.
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(boltdb.EmployeeBucket))
+		upgrade := boltdb.Migrations[0].Rewrites[0].Upgrade
+		v, err := upgrade([]byte("e00"), b.Get([]byte("e00")))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("e00"), v)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := boltdb.Migrate(db, 2); err != nil {
+		t.Fatal(err)
+	}
+	err = db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(boltdb.EmployeeBucket)).ForEach(func(k, v []byte) error {
+			if _, err := boltdb.DecodeEmployee(v); err != nil {
+				t.Errorf("employee %s: %v", k, err)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	db := openDB(t)
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(boltdb.MetaBucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(boltdb.SchemaVersionKey), []byte(strconv.Itoa(99)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := boltdb.Migrate(db, boltdb.DefaultMigrationBatch); err == nil {
+		t.Fatal("migrating a newer schema succeeded")
+	}
+}
+
+func TestDecodeRejectsUnmigratedRecords(t *testing.T) {
+	if _, err := boltdb.DecodeEmployee([]byte(`{"id":"e1","department:id":"eng"}`)); err == nil {
+		t.Fatal("decoded a legacy record")
+	}
+}
+
+func TestDecodeToleratesRecordsWithoutProfile(t *testing.T) {
+	// Written before the profile and status fields were added to the encoding
+	e, err := boltdb.DecodeEmployee([]byte(`{"v":2,"data":{"id":"e1","name":"Ada","position":"Dev","department_id":"eng","version":4}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.ID != "e1" || e.DepartmentId != "eng" || e.Version != 4 || e.Email != "" || e.HireDate != "" || e.Status != employee.Active {
+		t.Errorf("decoded %+v", e)
+	}
+}
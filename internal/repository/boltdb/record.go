@@ -0,0 +1,152 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+)
+
+// RecordVersion is the encoding of the records written by this build. Every
+// record is stored as an envelope naming its encoding:
+//
+//	{"v":2,"data":{"id":"e1","name":"Ada","department_id":"eng",...}}
+//
+// Version 1 records are the bare JSON of the domain models, written before
+// the envelope existed. Migrate upgrades older records when the database is
+// opened, so only the current encoding is decoded.
+//
+// Fields added to an encoding are optional, so records written before them
+// decode with the field empty and need no new version; only a change that
+// older records cannot be read as takes a migration.
+const RecordVersion = 2
+
+type envelope struct {
+	V    int             `json:"v"`
+	Data json.RawMessage `json:"data"`
+}
+
+// employeeRecord is the stored form of an employee, kept apart from the API
+// representation so each can change without the other.
+type employeeRecord struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Position     string `json:"position"`
+	DepartmentID string `json:"department_id"`
+	ManagerID    string `json:"manager_id,omitempty"`
+	// The profile fields, added in the same encoding.
+	Email           string `json:"email,omitempty"`
+	Phone           string `json:"phone,omitempty"`
+	HireDate        string `json:"hire_date,omitempty"`
+	TerminationDate string `json:"termination_date,omitempty"`
+	EmploymentType  string `json:"employment_type,omitempty"`
+	Location        string `json:"location,omitempty"`
+	CostCenter      string `json:"cost_center,omitempty"`
+	// Status is missing from the records written before the lifecycle,
+	// whose employees are active.
+	Status       string `json:"status,omitempty"`
+	StatusDate   string `json:"status_date,omitempty"`
+	StatusReason string `json:"status_reason,omitempty"`
+	Version      int64  `json:"version"`
+}
+
+// departmentRecord is the stored form of a department.
+type departmentRecord struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	ParentID string `json:"parent_id,omitempty"`
+	HeadID   string `json:"head_id,omitempty"`
+	Version  int64  `json:"version"`
+}
+
+func EncodeEmployee(e employee.Employee) ([]byte, error) {
+	return encode(employeeRecord{
+		ID:              e.ID,
+		Name:            e.Name,
+		Position:        e.Position,
+		DepartmentID:    e.DepartmentId,
+		ManagerID:       e.ManagerID,
+		Email:           e.Email,
+		Phone:           e.Phone,
+		HireDate:        e.HireDate,
+		TerminationDate: e.TerminationDate,
+		EmploymentType:  string(e.EmploymentType),
+		Location:        e.Location,
+		CostCenter:      e.CostCenter,
+		Status:          string(e.Status),
+		StatusDate:      e.StatusDate,
+		StatusReason:    e.StatusReason,
+		Version:         e.Version,
+	})
+}
+
+func DecodeEmployee(data []byte) (employee.Employee, error) {
+	r := employeeRecord{Status: string(employee.Active)}
+	err := decode(data, &r)
+	return employee.Employee{
+		ID:              r.ID,
+		Name:            r.Name,
+		Position:        r.Position,
+		DepartmentId:    r.DepartmentID,
+		ManagerID:       r.ManagerID,
+		Email:           r.Email,
+		Phone:           r.Phone,
+		HireDate:        r.HireDate,
+		TerminationDate: r.TerminationDate,
+		EmploymentType:  employee.EmploymentType(r.EmploymentType),
+		Location:        r.Location,
+		CostCenter:      r.CostCenter,
+		Status:          employee.Status(r.Status),
+		StatusDate:      r.StatusDate,
+		StatusReason:    r.StatusReason,
+		Version:         r.Version,
+	}, err
+}
+
+func EncodeDepartment(d department.Department) ([]byte, error) {
+	return encode(departmentRecord{ID: d.ID, Name: d.Name, ParentID: d.ParentID, HeadID: d.HeadID, Version: d.Version})
+}
+
+func DecodeDepartment(data []byte) (department.Department, error) {
+	var r departmentRecord
+	err := decode(data, &r)
+	return department.Department{ID: r.ID, Name: r.Name, ParentID: r.ParentID, HeadID: r.HeadID, Version: r.Version}, err
+}
+
+func encode(record any) ([]byte, error) {
+	return encodeAs(RecordVersion, record)
+}
+
+// encodeAs wraps record in the envelope of encoding version v, which
+// migrations use to write the encoding they upgrade to.
+func encodeAs(v int, record any) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{V: v, Data: data})
+}
+
+func decode(data []byte, record any) error {
+	v, payload, err := recordVersion(data)
+	if err != nil {
+		return err
+	}
+	if v != RecordVersion {
+		return fmt.Errorf("record encoded with version %d, expected %d", v, RecordVersion)
+	}
+	return json.Unmarshal(payload, record)
+}
+
+// recordVersion returns the encoding version of a stored record and its
+// payload, the whole record for version 1.
+func recordVersion(data []byte) (int, []byte, error) {
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return 0, nil, err
+	}
+	if e.V == 0 || e.Data == nil {
+		return 1, data, nil
+	}
+	return e.V, e.Data, nil
+}
@@ -0,0 +1,28 @@
+package department
+
+import (
+	"template-golang/internal/domain/employee"
+)
+
+// employeeLookup returns the stored employee with the given ID and whether
+// it exists.
+type employeeLookup func(id string) (employee.Employee, bool, error)
+
+// checkHead verifies that headID may run the department deptID: the
+// employee exists and belongs to the department.
+func checkHead(deptID, headID string, lookup employeeLookup) error {
+	if headID == "" {
+		return nil
+	}
+	e, found, err := lookup(headID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrHeadNotFound
+	}
+	if e.DepartmentId != deptID {
+		return ErrHeadNotInDepartment
+	}
+	return nil
+}
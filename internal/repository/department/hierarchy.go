@@ -0,0 +1,65 @@
+package department
+
+import (
+	"template-golang/internal/domain/department"
+)
+
+// The helpers below implement the department tree on top of the lookups of
+// each backend, called inside the transaction of the operation.
+
+// lookupFunc returns the stored department with the given ID and whether it
+// exists.
+type lookupFunc func(id string) (department.Department, bool, error)
+
+// checkParent verifies that parentID may contain id: the parent exists and
+// is not below id.
+func checkParent(id, parentID string, lookup lookupFunc) error {
+	if parentID == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	for current := parentID; current != ""; {
+		if current == id {
+			return ErrDepartmentCycle
+		}
+		if seen[current] {
+			// A cycle above id, which can only come from a corrupted store
+			return ErrDepartmentCycle
+		}
+		seen[current] = true
+		d, found, err := lookup(current)
+		if err != nil {
+			return err
+		}
+		if !found {
+			if current == parentID {
+				return ErrParentNotFound
+			}
+			return nil
+		}
+		current = d.ParentID
+	}
+	return nil
+}
+
+// collectChildren returns the children of id listed by direct, or every
+// department below id, breadth first, when transitive is set.
+func collectChildren(id string, transitive bool, direct func(id string) ([]department.Department, error)) ([]department.Department, error) {
+	children, err := direct(id)
+	if err != nil || !transitive {
+		return children, err
+	}
+	seen := map[string]bool{id: true}
+	for i := 0; i < len(children); i++ {
+		if seen[children[i].ID] {
+			continue
+		}
+		seen[children[i].ID] = true
+		below, err := direct(children[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, below...)
+	}
+	return children, nil
+}
@@ -0,0 +1,77 @@
+package department
+
+import (
+	"context"
+	"log/slog"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/logging"
+	"time"
+)
+
+// InstrumentedRepository reports the latency and outcome of every operation
+// of the wrapped Repository to observe, and logs it at debug level with the
+// request-scoped logger of its context.
+type InstrumentedRepository struct {
+	next    Repository
+	observe func(operation string, took time.Duration, err error)
+}
+
+func NewInstrumentedRepository(next Repository, observe func(operation string, took time.Duration, err error)) *InstrumentedRepository {
+	return &InstrumentedRepository{next: next, observe: observe}
+}
+
+func (r *InstrumentedRepository) done(ctx context.Context, operation string, start time.Time, err *error) {
+	took := time.Since(start)
+	r.observe(operation, took, *err)
+	attrs := []slog.Attr{
+		slog.String("repository", "department"),
+		slog.String("operation", operation),
+		slog.Duration("duration", took),
+	}
+	if *err != nil {
+		attrs = append(attrs, slog.String("error", (*err).Error()))
+	}
+	logging.FromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "repository operation", attrs...)
+}
+
+func (r *InstrumentedRepository) CreateDepartment(ctx context.Context, e department.Department) (_ *department.Department, err error) {
+	defer r.done(ctx, "create", time.Now(), &err)
+	return r.next.CreateDepartment(ctx, e)
+}
+
+func (r *InstrumentedRepository) GetAllDepartments(ctx context.Context) (_ []department.Department, err error) {
+	defer r.done(ctx, "get_all", time.Now(), &err)
+	return r.next.GetAllDepartments(ctx)
+}
+
+func (r *InstrumentedRepository) ListDepartments(ctx context.Context, q department.ListQuery) (_ listing.Page[department.Department], err error) {
+	defer r.done(ctx, "list", time.Now(), &err)
+	return r.next.ListDepartments(ctx, q)
+}
+
+func (r *InstrumentedRepository) GetDepartmentByID(ctx context.Context, id string) (_ *department.Department, err error) {
+	defer r.done(ctx, "get", time.Now(), &err)
+	return r.next.GetDepartmentByID(ctx, id)
+}
+
+func (r *InstrumentedRepository) UpdateDepartmentByID(ctx context.Context, id string, cond version.Condition, update department.Department) (_ *department.Department, err error) {
+	defer r.done(ctx, "update", time.Now(), &err)
+	return r.next.UpdateDepartmentByID(ctx, id, cond, update)
+}
+
+func (r *InstrumentedRepository) PatchDepartmentByID(ctx context.Context, id string, cond version.Condition, apply func(department.Department) (department.Department, error)) (_ *department.Department, err error) {
+	defer r.done(ctx, "patch", time.Now(), &err)
+	return r.next.PatchDepartmentByID(ctx, id, cond, apply)
+}
+
+func (r *InstrumentedRepository) DeleteDepartmentByID(ctx context.Context, id string, cond version.Condition, opts department.DeleteOptions) (err error) {
+	defer r.done(ctx, "delete", time.Now(), &err)
+	return r.next.DeleteDepartmentByID(ctx, id, cond, opts)
+}
+
+func (r *InstrumentedRepository) GetChildren(ctx context.Context, id string, transitive bool) (_ []department.Department, err error) {
+	defer r.done(ctx, "get_children", time.Now(), &err)
+	return r.next.GetChildren(ctx, id, transitive)
+}
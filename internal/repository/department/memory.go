@@ -0,0 +1,228 @@
+package department
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/repository/memory"
+)
+
+// MemoryRepository keeps departments in a memory.Store, for tests and for
+// running without a database file. It behaves like BoltRepository.
+type MemoryRepository struct {
+	store *memory.Store
+}
+
+func NewMemoryRepository(store *memory.Store) *MemoryRepository {
+	return &MemoryRepository{store: store}
+}
+
+func (r *MemoryRepository) GetAllDepartments(ctx context.Context) ([]department.Department, error) {
+	var departments []department.Department
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		for _, d := range tx.Departments {
+			departments = append(departments, d)
+		}
+		return nil
+	})
+	slices.SortFunc(departments, func(a, b department.Department) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return departments, errs.Storage(err)
+}
+
+func (r *MemoryRepository) ListDepartments(ctx context.Context, q department.ListQuery) (listing.Page[department.Department], error) {
+	after, err := listing.DecodeCursor(q.Sort, q.After)
+	if err != nil {
+		return listing.Page[department.Department]{}, err
+	}
+
+	namePrefix := strings.ToLower(q.NamePrefix)
+	key := func(d department.Department) []byte { return []byte(d.ID) }
+	if q.Sort == department.SortByName {
+		key = func(d department.Department) []byte { return listing.SortKey(d.Name, d.ID) }
+	}
+	match := func(d department.Department) bool {
+		return strings.HasPrefix(strings.ToLower(d.Name), namePrefix)
+	}
+
+	var page listing.Page[department.Department]
+	err = r.store.View(ctx, func(tx *memory.Tx) error {
+		page, err = memory.List(ctx, tx.Departments, key, after, q.Limit, q.Sort, match)
+		return err
+	})
+	return page, errs.Storage(err)
+}
+
+func (r *MemoryRepository) CreateDepartment(ctx context.Context, d department.Department) (*department.Department, error) {
+	err := r.store.Update(ctx, func(tx *memory.Tx) error {
+		if tx.DepartmentExists(d.ID) {
+			return ErrDepartmentExists
+		}
+		if err := checkParent(d.ID, d.ParentID, memoryLookup(tx)); err != nil {
+			return err
+		}
+		if err := checkHead(d.ID, d.HeadID, memoryEmployeeLookup(tx)); err != nil {
+			return err
+		}
+		d.Version = 1
+		tx.Departments[d.ID] = d
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &d, nil
+}
+
+func (r *MemoryRepository) GetDepartmentByID(ctx context.Context, id string) (*department.Department, error) {
+	var dept department.Department
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		var ok bool
+		if dept, ok = tx.Departments[id]; !ok {
+			return errs.NotFound("Department %s not found", id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &dept, nil
+}
+
+func (r *MemoryRepository) UpdateDepartmentByID(ctx context.Context, id string, cond version.Condition, update department.Department) (*department.Department, error) {
+	return r.PatchDepartmentByID(ctx, id, cond, func(dept department.Department) (department.Department, error) {
+		dept.Name = update.Name
+		dept.ParentID = update.ParentID
+		dept.HeadID = update.HeadID
+		return dept, nil
+	})
+}
+
+func (r *MemoryRepository) PatchDepartmentByID(ctx context.Context, id string, cond version.Condition, apply func(department.Department) (department.Department, error)) (*department.Department, error) {
+	var patched department.Department
+	err := r.store.Update(ctx, func(tx *memory.Tx) error {
+		dept, ok := tx.Departments[id]
+		if !ok {
+			return errs.NotFound("Department %s not found", id)
+		}
+		if !cond.Holds(dept.Version) {
+			return errs.PreconditionFailed("Department %s has been modified", id)
+		}
+
+		var err error
+		if patched, err = apply(dept); err != nil {
+			return err
+		}
+		patched.ID = id
+		patched.Version = dept.Version + 1
+		if patched.ParentID != dept.ParentID {
+			if err := checkParent(id, patched.ParentID, memoryLookup(tx)); err != nil {
+				return err
+			}
+		}
+		if patched.HeadID != dept.HeadID {
+			if err := checkHead(id, patched.HeadID, memoryEmployeeLookup(tx)); err != nil {
+				return err
+			}
+		}
+		tx.Departments[id] = patched
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &patched, nil
+}
+
+func (r *MemoryRepository) DeleteDepartmentByID(ctx context.Context, id string, cond version.Condition, opts department.DeleteOptions) error {
+	err := r.store.Update(ctx, func(tx *memory.Tx) error {
+		dept, ok := tx.Departments[id]
+		if !ok {
+			return errs.NotFound("Department %s not found", id)
+		}
+		if !cond.Holds(dept.Version) {
+			return errs.PreconditionFailed("Department %s has been modified", id)
+		}
+		if len(tx.DepartmentIDsByParent(id)) > 0 {
+			return ErrDepartmentHasChildren
+		}
+
+		// Apply the delete policy to the employees of the department
+		staff := tx.EmployeeIDsByDepartment(id)
+		if len(staff) > 0 {
+			switch opts.Policy {
+			case department.DeleteCascade:
+				// Reports within the department go with their manager,
+				// those elsewhere have to be moved first
+				for _, empID := range staff {
+					for _, reportID := range tx.EmployeeIDsByManager(empID) {
+						if tx.Employees[reportID].DepartmentId != id {
+							return ErrStaffHasReports
+						}
+					}
+				}
+				for _, empID := range staff {
+					delete(tx.Employees, empID)
+				}
+			case department.DeleteReassign:
+				if opts.ReassignTo == id || !tx.DepartmentExists(opts.ReassignTo) {
+					return ErrInvalidReassignTarget
+				}
+				for _, empID := range staff {
+					emp := tx.Employees[empID]
+					emp.DepartmentId = opts.ReassignTo
+					emp.Version++
+					tx.Employees[empID] = emp
+				}
+			default:
+				return ErrDepartmentHasEmployees
+			}
+		}
+
+		delete(tx.Departments, id)
+		return nil
+	})
+	return errs.Storage(err)
+}
+
+func (r *MemoryRepository) GetChildren(ctx context.Context, id string, transitive bool) ([]department.Department, error) {
+	var children []department.Department
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		if !tx.DepartmentExists(id) {
+			return errs.NotFound("Department %s not found", id)
+		}
+		var err error
+		children, err = collectChildren(id, transitive, func(id string) ([]department.Department, error) {
+			children := []department.Department{}
+			for _, childID := range tx.DepartmentIDsByParent(id) {
+				children = append(children, tx.Departments[childID])
+			}
+			return children, ctx.Err()
+		})
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return children, nil
+}
+
+func memoryLookup(tx *memory.Tx) lookupFunc {
+	return func(id string) (department.Department, bool, error) {
+		d, ok := tx.Departments[id]
+		return d, ok, nil
+	}
+}
+
+func memoryEmployeeLookup(tx *memory.Tx) employeeLookup {
+	return func(id string) (employee.Employee, bool, error) {
+		e, ok := tx.Employees[id]
+		return e, ok, nil
+	}
+}
@@ -1,22 +1,63 @@
 package department
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"go.etcd.io/bbolt"
+	"slices"
+	"strings"
 	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/repository/boltdb"
 )
 
 const (
-	departmentBucket = "Departments"
+	departmentBucket = boltdb.DepartmentBucket
+)
+
+var (
+	// ErrDepartmentExists is returned when creating a department whose ID is taken.
+	ErrDepartmentExists = errs.Conflict("Department already exists")
+	// ErrDepartmentHasEmployees is returned when deleting a department that
+	// still has staff under the reject policy.
+	ErrDepartmentHasEmployees = errs.Conflict("Department still has employees")
+	// ErrInvalidReassignTarget is returned when the reassign policy names a
+	// department that does not exist or is the one being deleted.
+	ErrInvalidReassignTarget = errs.Validation("Invalid department to reassign employees to")
+	// ErrParentNotFound is returned when a department names a parent that
+	// does not exist.
+	ErrParentNotFound = errs.Validation("Parent department does not exist")
+	// ErrDepartmentCycle is returned when a department would end up below
+	// itself.
+	ErrDepartmentCycle = errs.Validation("Parent would create a department cycle")
+	// ErrDepartmentHasChildren is returned when deleting a department that
+	// still contains other departments, whatever the delete policy.
+	ErrDepartmentHasChildren = errs.Conflict("Department has child departments")
+	// ErrStaffHasReports is returned when the cascade policy would delete
+	// the manager of employees in other departments, like deleting an
+	// employee with direct reports.
+	ErrStaffHasReports = errs.Conflict("Employees of other departments report to the department staff")
+	// ErrHeadNotFound is returned when a department names a head that does
+	// not exist.
+	ErrHeadNotFound = errs.Validation("Head employee does not exist")
+	// ErrHeadNotInDepartment is returned when a department names a head
+	// working in another department.
+	ErrHeadNotInDepartment = errs.Validation("Head must be an employee of the department")
 )
 
 type Repository interface {
-	CreateDepartment(e department.Department) error
-	GetAllDepartments() ([]department.Department, error)
-	GetDepartmentByID(id string) (*department.Department, error)
-	UpdateDepartmentByID(id string, update department.Department) error
-	DeleteDepartmentByID(id string) error
+	CreateDepartment(ctx context.Context, e department.Department) (*department.Department, error)
+	GetAllDepartments(ctx context.Context) ([]department.Department, error)
+	ListDepartments(ctx context.Context, q department.ListQuery) (listing.Page[department.Department], error)
+	GetDepartmentByID(ctx context.Context, id string) (*department.Department, error)
+	UpdateDepartmentByID(ctx context.Context, id string, cond version.Condition, update department.Department) (*department.Department, error)
+	PatchDepartmentByID(ctx context.Context, id string, cond version.Condition, apply func(department.Department) (department.Department, error)) (*department.Department, error)
+	DeleteDepartmentByID(ctx context.Context, id string, cond version.Condition, opts department.DeleteOptions) error
+	// GetChildren returns the departments directly under id, or with
+	// transitive every department below id, level by level in ID order.
+	GetChildren(ctx context.Context, id string, transitive bool) ([]department.Department, error)
 }
 
 type BoltRepository struct {
@@ -27,100 +68,220 @@ func NewBoltRepository(db *bbolt.DB) *BoltRepository {
 	return &BoltRepository{db: db}
 }
 
-func (r *BoltRepository) GetAllDepartments() ([]department.Department, error) {
+func (r *BoltRepository) GetAllDepartments(ctx context.Context) ([]department.Department, error) {
 	var departments []department.Department
-	err := r.db.View(func(tx *bbolt.Tx) error {
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(departmentBucket))
 		if b == nil {
 			return nil // Nenhum bucket, sem dados
 		}
 		return b.ForEach(func(k, v []byte) error {
-			var emp department.Department
-			if err := json.Unmarshal(v, &emp); err != nil {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			emp, err := boltdb.DecodeDepartment(v)
+			if err != nil {
 				return err
 			}
 			departments = append(departments, emp)
 			return nil
 		})
 	})
-	return departments, err
+	return departments, errs.Storage(err)
+}
+
+// ListDepartments returns one page of departments, walking the sort index
+// with a bbolt cursor.
+func (r *BoltRepository) ListDepartments(ctx context.Context, q department.ListQuery) (listing.Page[department.Department], error) {
+	after, err := listing.DecodeCursor(q.Sort, q.After)
+	if err != nil {
+		return listing.Page[department.Department]{}, err
+	}
+
+	namePrefix := strings.ToLower(q.NamePrefix)
+	rng := boltdb.Range{After: after, Limit: q.Limit}
+	if q.Sort == department.SortByName {
+		rng.Index = boltdb.DepartmentNameIndexBucket
+		rng.Prefix = []byte(namePrefix)
+	}
+	match := func(d department.Department) bool {
+		return strings.HasPrefix(strings.ToLower(d.Name), namePrefix)
+	}
+
+	var page listing.Page[department.Department]
+	err = boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
+		page, err = boltdb.List(ctx, tx, departmentBucket, rng, q.Sort, boltdb.DecodeDepartment, match)
+		return err
+	})
+	return page, errs.Storage(err)
 }
 
-func (r *BoltRepository) CreateDepartment(e department.Department) error {
-	return r.db.Update(func(tx *bbolt.Tx) error {
+func (r *BoltRepository) CreateDepartment(ctx context.Context, e department.Department) (*department.Department, error) {
+	err := boltdb.Update(ctx, r.db, func(tx *bbolt.Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(departmentBucket))
 		if err != nil {
 			return err
 		}
-		encoded, err := json.Marshal(e)
+		if exists := b.Get([]byte(e.ID)); exists != nil {
+			return ErrDepartmentExists
+		}
+		if err := checkParent(e.ID, e.ParentID, boltLookup(tx)); err != nil {
+			return err
+		}
+		if err := checkHead(e.ID, e.HeadID, boltEmployeeLookup(tx)); err != nil {
+			return err
+		}
+		e.Version = 1
+		encoded, err := boltdb.EncodeDepartment(e)
 		if err != nil {
 			return err
 		}
-		return b.Put([]byte(e.ID), encoded)
+		if err := b.Put([]byte(e.ID), encoded); err != nil {
+			return err
+		}
+		return boltdb.IndexDepartment(tx, e)
 	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &e, nil
 }
 
-func (r *BoltRepository) GetDepartmentByID(id string) (*department.Department, error) {
+func (r *BoltRepository) GetDepartmentByID(ctx context.Context, id string) (*department.Department, error) {
 	var request *department.Department
-	err := r.db.View(func(tx *bbolt.Tx) error {
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(departmentBucket))
 		if b == nil {
-			return errors.New("Department bucket does not exist")
+			return errs.NotFound("Department %s not found", id)
 		}
 		v := b.Get([]byte(id)) // Convertendo ID int para string para busca
 		if v == nil {
-			return errors.New("Department not found")
+			return errs.NotFound("Department %s not found", id)
 		}
-		return json.Unmarshal(v, &request)
+		decoded, err := boltdb.DecodeDepartment(v)
+		request = &decoded
+		return err
 	})
 	if err != nil {
-		return nil, err
+		return nil, errs.Storage(err)
 	}
 	return request, nil
 } // Implementar os outros métodos CRUD semelhantemente
 
-func (r *BoltRepository) UpdateDepartmentByID(id string, update department.Department) error {
-	return r.db.Update(func(tx *bbolt.Tx) error {
+func (r *BoltRepository) UpdateDepartmentByID(ctx context.Context, id string, cond version.Condition, update department.Department) (*department.Department, error) {
+	return r.PatchDepartmentByID(ctx, id, cond, func(dept department.Department) (department.Department, error) {
+		// Updating the department with new data
+		dept.Name = update.Name
+		dept.ParentID = update.ParentID
+		dept.HeadID = update.HeadID
+		return dept, nil
+	})
+}
+
+// PatchDepartmentByID replaces the department with the result of apply,
+// called with the stored department inside the update transaction.
+func (r *BoltRepository) PatchDepartmentByID(ctx context.Context, id string, cond version.Condition, apply func(department.Department) (department.Department, error)) (*department.Department, error) {
+	var patched department.Department
+	err := boltdb.Update(ctx, r.db, func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(departmentBucket))
 		if b == nil {
-			return errors.New("Department bucket does not exist")
+			return errs.NotFound("Department %s not found", id)
 		}
 
 		current := b.Get([]byte(id))
 		if current == nil {
-			return errors.New("Department not found")
+			return errs.NotFound("Department %s not found", id)
 		}
 
-		// Optionally, you might want to unmarshal the current department data
-		// and apply only specific changes or validate the changes
-		var emp department.Department
-		if err := json.Unmarshal(current, &emp); err != nil {
+		dept, err := boltdb.DecodeDepartment(current)
+		if err != nil {
 			return err
 		}
 
-		// Updating the department with new data
-		emp.Name = update.Name
+		if !cond.Holds(dept.Version) {
+			return errs.PreconditionFailed("Department %s has been modified", id)
+		}
+
+		if patched, err = apply(dept); err != nil {
+			return err
+		}
+		patched.ID = id
+		patched.Version = dept.Version + 1
 
-		// Marshal the updated department back to JSON
-		updated, err := json.Marshal(emp)
+		if patched.ParentID != dept.ParentID {
+			if err := checkParent(id, patched.ParentID, boltLookup(tx)); err != nil {
+				return err
+			}
+		}
+		if patched.HeadID != dept.HeadID {
+			if err := checkHead(id, patched.HeadID, boltEmployeeLookup(tx)); err != nil {
+				return err
+			}
+		}
+
+		if err := boltdb.UnindexDepartment(tx, dept); err != nil {
+			return err
+		}
+
+		// Encode the updated department
+		updated, err := boltdb.EncodeDepartment(patched)
 		if err != nil {
 			return err
 		}
 
 		// Save the updated department back to the database
-		return b.Put([]byte(id), updated)
+		if err := b.Put([]byte(id), updated); err != nil {
+			return err
+		}
+		return boltdb.IndexDepartment(tx, patched)
 	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &patched, nil
 }
 
-func (r *BoltRepository) DeleteDepartmentByID(id string) error {
-	return r.db.Update(func(tx *bbolt.Tx) error {
+func (r *BoltRepository) DeleteDepartmentByID(ctx context.Context, id string, cond version.Condition, opts department.DeleteOptions) error {
+	err := boltdb.Update(ctx, r.db, func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(departmentBucket))
 		if b == nil {
-			return errors.New("Department bucket does not exist")
+			return errs.NotFound("Department %s not found", id)
 		}
 
-		if exists := b.Get([]byte(id)); exists == nil {
-			return errors.New("Department not found")
+		current := b.Get([]byte(id))
+		if current == nil {
+			return errs.NotFound("Department %s not found", id)
+		}
+
+		dept, err := boltdb.DecodeDepartment(current)
+		if err != nil {
+			return err
+		}
+		if !cond.Holds(dept.Version) {
+			return errs.PreconditionFailed("Department %s has been modified", id)
+		}
+		if len(boltdb.DepartmentIDsByParent(tx, id)) > 0 {
+			return ErrDepartmentHasChildren
+		}
+
+		// Apply the delete policy to the employees of the department
+		staff := boltdb.EmployeeIDsByDepartment(tx, id)
+		if len(staff) > 0 {
+			var err error
+			switch opts.Policy {
+			case department.DeleteCascade:
+				err = deleteEmployees(ctx, tx, staff)
+			case department.DeleteReassign:
+				if opts.ReassignTo == id || !boltdb.DepartmentExists(tx, opts.ReassignTo) {
+					return ErrInvalidReassignTarget
+				}
+				err = reassignEmployees(ctx, tx, opts.ReassignTo, staff)
+			default:
+				return ErrDepartmentHasEmployees
+			}
+			if err != nil {
+				return err
+			}
 		}
 
 		// Delete the department
@@ -128,6 +289,141 @@ func (r *BoltRepository) DeleteDepartmentByID(id string) error {
 			return err
 		}
 
-		return nil
+		return boltdb.UnindexDepartment(tx, dept)
 	})
+	return errs.Storage(err)
+}
+
+func (r *BoltRepository) GetChildren(ctx context.Context, id string, transitive bool) ([]department.Department, error) {
+	var children []department.Department
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
+		if !boltdb.DepartmentExists(tx, id) {
+			return errs.NotFound("Department %s not found", id)
+		}
+		lookup := boltLookup(tx)
+		var err error
+		children, err = collectChildren(id, transitive, func(id string) ([]department.Department, error) {
+			children := []department.Department{}
+			for _, childID := range boltdb.DepartmentIDsByParent(tx, id) {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				child, found, err := lookup(childID)
+				if err != nil {
+					return nil, err
+				}
+				if found {
+					children = append(children, child)
+				}
+			}
+			return children, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return children, nil
+}
+
+func boltLookup(tx *bbolt.Tx) lookupFunc {
+	return func(id string) (department.Department, bool, error) {
+		b := tx.Bucket([]byte(departmentBucket))
+		if b == nil {
+			return department.Department{}, false, nil
+		}
+		v := b.Get([]byte(id))
+		if v == nil {
+			return department.Department{}, false, nil
+		}
+		d, err := boltdb.DecodeDepartment(v)
+		return d, err == nil, err
+	}
+}
+
+func boltEmployeeLookup(tx *bbolt.Tx) employeeLookup {
+	return func(id string) (employee.Employee, bool, error) {
+		b := tx.Bucket([]byte(boltdb.EmployeeBucket))
+		if b == nil {
+			return employee.Employee{}, false, nil
+		}
+		v := b.Get([]byte(id))
+		if v == nil {
+			return employee.Employee{}, false, nil
+		}
+		e, err := boltdb.DecodeEmployee(v)
+		return e, err == nil, err
+	}
+}
+
+func deleteEmployees(ctx context.Context, tx *bbolt.Tx, ids []string) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(boltdb.EmployeeBucket))
+	if err != nil {
+		return err
+	}
+	// Reports within the department go with their manager, those elsewhere
+	// have to be moved first
+	for _, id := range ids {
+		for _, reportID := range boltdb.EmployeeIDsByManager(tx, id) {
+			if !slices.Contains(ids, reportID) {
+				return ErrStaffHasReports
+			}
+		}
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		current := b.Get([]byte(id))
+		if current == nil {
+			continue
+		}
+		emp, err := boltdb.DecodeEmployee(current)
+		if err != nil {
+			return err
+		}
+		if err := boltdb.UnindexEmployee(tx, emp); err != nil {
+			return err
+		}
+		if err := b.Delete([]byte(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reassignEmployees(ctx context.Context, tx *bbolt.Tx, to string, ids []string) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(boltdb.EmployeeBucket))
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		current := b.Get([]byte(id))
+		if current == nil {
+			continue
+		}
+		emp, err := boltdb.DecodeEmployee(current)
+		if err != nil {
+			return err
+		}
+		if err := boltdb.UnindexEmployee(tx, emp); err != nil {
+			return err
+		}
+		emp.DepartmentId = to
+		emp.Version++
+		updated, err := boltdb.EncodeEmployee(emp)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(id), updated); err != nil {
+			return err
+		}
+		if err := boltdb.IndexEmployee(tx, emp); err != nil {
+			return err
+		}
+	}
+	return nil
 }
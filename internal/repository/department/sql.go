@@ -0,0 +1,325 @@
+package department
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/repository/sqldb"
+)
+
+const departmentColumns = `id, name, COALESCE(parent_id, ''), COALESCE(head_id, ''), version`
+
+// SQLRepository keeps departments in the departments table of a SQL
+// database. It behaves like BoltRepository.
+type SQLRepository struct {
+	db      *sql.DB
+	dialect sqldb.Dialect
+}
+
+func NewSQLRepository(db *sql.DB, d sqldb.Dialect) *SQLRepository {
+	return &SQLRepository{db: db, dialect: d}
+}
+
+func scanDepartment(row interface{ Scan(...any) error }) (department.Department, error) {
+	var d department.Department
+	err := row.Scan(&d.ID, &d.Name, &d.ParentID, &d.HeadID, &d.Version)
+	return d, err
+}
+
+func (r *SQLRepository) GetAllDepartments(ctx context.Context) ([]department.Department, error) {
+	var departments []department.Department
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT `+departmentColumns+` FROM departments ORDER BY id`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			d, err := scanDepartment(rows)
+			if err != nil {
+				return err
+			}
+			departments = append(departments, d)
+		}
+		return rows.Err()
+	})
+	return departments, errs.Storage(err)
+}
+
+// ListDepartments returns one page of departments, seeking past the cursor
+// on the index of the sort column.
+func (r *SQLRepository) ListDepartments(ctx context.Context, q department.ListQuery) (listing.Page[department.Department], error) {
+	after, err := listing.DecodeCursor(q.Sort, q.After)
+	if err != nil {
+		return listing.Page[department.Department]{}, err
+	}
+
+	l := sqldb.Listing{Table: "departments", Columns: departmentColumns}
+	key := func(d department.Department) []byte { return []byte(d.ID) }
+	if q.Sort == department.SortByName {
+		l.SortColumn = "name_key"
+		key = func(d department.Department) []byte { return listing.SortKey(d.Name, d.ID) }
+	}
+	if q.NamePrefix != "" {
+		l.Where = append(l.Where, `name_key LIKE ? ESCAPE '\'`)
+		l.Args = append(l.Args, sqldb.PrefixPattern(strings.ToLower(q.NamePrefix)))
+	}
+
+	var page listing.Page[department.Department]
+	err = sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		page, err = sqldb.List(ctx, tx, l, q.Sort, after, q.Limit, func(rows *sql.Rows) (department.Department, error) {
+			return scanDepartment(rows)
+		}, key)
+		return err
+	})
+	return page, errs.Storage(err)
+}
+
+func (r *SQLRepository) CreateDepartment(ctx context.Context, d department.Department) (*department.Department, error) {
+	err := sqldb.Update(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		if err := r.checkParent(ctx, tx, d.ID, d.ParentID); err != nil {
+			return err
+		}
+		if err := r.checkHead(ctx, tx, d.ID, d.HeadID); err != nil {
+			return err
+		}
+		d.Version = 1
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO departments (id, name, name_key, parent_id, head_id, version) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)`,
+			d.ID, d.Name, strings.ToLower(d.Name), d.ParentID, d.HeadID, d.Version)
+		if r.dialect.IsUniqueViolation(err) {
+			return ErrDepartmentExists
+		}
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &d, nil
+}
+
+func (r *SQLRepository) GetDepartmentByID(ctx context.Context, id string) (*department.Department, error) {
+	var dept department.Department
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		var err error
+		dept, err = scanDepartment(tx.QueryRowContext(ctx, `SELECT `+departmentColumns+` FROM departments WHERE id = ?`, id))
+		if err == sql.ErrNoRows {
+			return errs.NotFound("Department %s not found", id)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &dept, nil
+}
+
+func (r *SQLRepository) UpdateDepartmentByID(ctx context.Context, id string, cond version.Condition, update department.Department) (*department.Department, error) {
+	return r.PatchDepartmentByID(ctx, id, cond, func(dept department.Department) (department.Department, error) {
+		dept.Name = update.Name
+		dept.ParentID = update.ParentID
+		dept.HeadID = update.HeadID
+		return dept, nil
+	})
+}
+
+// PatchDepartmentByID replaces the department with the result of apply,
+// called with the stored department inside the update transaction.
+func (r *SQLRepository) PatchDepartmentByID(ctx context.Context, id string, cond version.Condition, apply func(department.Department) (department.Department, error)) (*department.Department, error) {
+	var patched department.Department
+	err := sqldb.Update(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		dept, err := r.current(ctx, tx, id, cond, r.dialect.ForNoKeyUpdate)
+		if err != nil {
+			return err
+		}
+
+		if patched, err = apply(dept); err != nil {
+			return err
+		}
+		patched.ID = id
+		patched.Version = dept.Version + 1
+
+		if patched.ParentID != dept.ParentID {
+			if err := r.checkParent(ctx, tx, id, patched.ParentID); err != nil {
+				return err
+			}
+		}
+		if patched.HeadID != dept.HeadID {
+			if err := r.checkHead(ctx, tx, id, patched.HeadID); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE departments SET name = ?, name_key = ?, parent_id = NULLIF(?, ''), head_id = NULLIF(?, ''), version = ? WHERE id = ?`,
+			patched.Name, strings.ToLower(patched.Name), patched.ParentID, patched.HeadID, patched.Version, id)
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &patched, nil
+}
+
+func (r *SQLRepository) DeleteDepartmentByID(ctx context.Context, id string, cond version.Condition, opts department.DeleteOptions) error {
+	err := sqldb.Update(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		if _, err := r.current(ctx, tx, id, cond, r.dialect.ForUpdate); err != nil {
+			return err
+		}
+		hasChildren, err := sqldb.Exists(ctx, tx, `SELECT 1 FROM departments WHERE parent_id = ? LIMIT 1`, id)
+		if err != nil {
+			return err
+		}
+		if hasChildren {
+			return ErrDepartmentHasChildren
+		}
+
+		// Apply the delete policy to the employees of the department
+		hasStaff, err := sqldb.Exists(ctx, tx, `SELECT 1 FROM employees WHERE department_id = ? LIMIT 1`, id)
+		if err != nil {
+			return err
+		}
+		if hasStaff {
+			switch opts.Policy {
+			case department.DeleteCascade:
+				// Reports within the department go with their manager,
+				// those elsewhere have to be moved first
+				var hasReports bool
+				hasReports, err = sqldb.Exists(ctx, tx,
+					`SELECT 1 FROM employees
+					WHERE department_id <> ? AND manager_id IN (SELECT id FROM employees WHERE department_id = ?) LIMIT 1`,
+					id, id)
+				if err != nil {
+					return err
+				}
+				if hasReports {
+					return ErrStaffHasReports
+				}
+				_, err = tx.ExecContext(ctx, `UPDATE departments SET head_id = NULL WHERE id = ?`, id)
+				if err != nil {
+					return err
+				}
+				_, err = tx.ExecContext(ctx, `DELETE FROM employees WHERE department_id = ?`, id)
+			case department.DeleteReassign:
+				var exists bool
+				if exists, err = sqldb.DepartmentExists(ctx, tx, opts.ReassignTo); err != nil {
+					return err
+				}
+				if opts.ReassignTo == id || !exists {
+					return ErrInvalidReassignTarget
+				}
+				_, err = tx.ExecContext(ctx,
+					`UPDATE employees SET department_id = ?, version = version + 1 WHERE department_id = ?`,
+					opts.ReassignTo, id)
+			default:
+				return ErrDepartmentHasEmployees
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM departments WHERE id = ?`, id)
+		if r.dialect.IsForeignKeyViolation(err) {
+			// An employee was assigned by a concurrent transaction
+			return ErrDepartmentHasEmployees
+		}
+		return err
+	})
+	return errs.Storage(err)
+}
+
+func (r *SQLRepository) GetChildren(ctx context.Context, id string, transitive bool) ([]department.Department, error) {
+	var children []department.Department
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		exists, err := sqldb.DepartmentExists(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errs.NotFound("Department %s not found", id)
+		}
+		children, err = collectChildren(id, transitive, func(id string) ([]department.Department, error) {
+			return queryDepartments(ctx, tx, `SELECT `+departmentColumns+` FROM departments WHERE parent_id = ? ORDER BY id`, id)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return children, nil
+}
+
+// current reads the department about to be modified, locking its row with
+// lock, and checks cond against its version.
+func (r *SQLRepository) current(ctx context.Context, tx *sqldb.Tx, id string, cond version.Condition, lock string) (department.Department, error) {
+	dept, err := scanDepartment(tx.QueryRowContext(ctx, `SELECT `+departmentColumns+` FROM departments WHERE id = ?`+lock, id))
+	if err == sql.ErrNoRows {
+		return dept, errs.NotFound("Department %s not found", id)
+	}
+	if err != nil {
+		return dept, err
+	}
+	if !cond.Holds(dept.Version) {
+		return dept, errs.PreconditionFailed("Department %s has been modified", id)
+	}
+	return dept, nil
+}
+
+// checkParent holds the hierarchy lock while checking the department tree,
+// and locks the row of the parent so it stays until the transaction ends.
+func (r *SQLRepository) checkParent(ctx context.Context, tx *sqldb.Tx, id, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	if r.dialect.HierarchyLock != "" {
+		if _, err := tx.ExecContext(ctx, r.dialect.HierarchyLock); err != nil {
+			return err
+		}
+	}
+	if _, err := sqldb.Exists(ctx, tx, `SELECT 1 FROM departments WHERE id = ?`+r.dialect.ForShare, parentID); err != nil {
+		return err
+	}
+	return checkParent(id, parentID, func(id string) (department.Department, bool, error) {
+		d, err := scanDepartment(tx.QueryRowContext(ctx, `SELECT `+departmentColumns+` FROM departments WHERE id = ?`, id))
+		if err == sql.ErrNoRows {
+			return d, false, nil
+		}
+		return d, err == nil, err
+	})
+}
+
+// checkHead locks the row of the head so it stays in the department until
+// the transaction ends.
+func (r *SQLRepository) checkHead(ctx context.Context, tx *sqldb.Tx, deptID, headID string) error {
+	return checkHead(deptID, headID, func(id string) (employee.Employee, bool, error) {
+		var e employee.Employee
+		err := tx.QueryRowContext(ctx, `SELECT id, department_id FROM employees WHERE id = ?`+r.dialect.ForShareRow, id).Scan(&e.ID, &e.DepartmentId)
+		if err == sql.ErrNoRows {
+			return e, false, nil
+		}
+		return e, err == nil, err
+	})
+}
+
+func queryDepartments(ctx context.Context, tx *sqldb.Tx, query string, args ...any) ([]department.Department, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	departments := []department.Department{}
+	for rows.Next() {
+		d, err := scanDepartment(rows)
+		if err != nil {
+			return nil, err
+		}
+		departments = append(departments, d)
+	}
+	return departments, rows.Err()
+}
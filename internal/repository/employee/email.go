@@ -0,0 +1,20 @@
+package employee
+
+// emailLookup returns the ID of the employee using email, empty when no
+// employee does.
+type emailLookup func(email string) (string, error)
+
+// checkEmail verifies that email is not used by an employee other than id.
+func checkEmail(id, email string, lookup emailLookup) error {
+	if email == "" {
+		return nil
+	}
+	owner, err := lookup(email)
+	if err != nil {
+		return err
+	}
+	if owner != "" && owner != id {
+		return ErrEmailTaken
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package employee
+
+import (
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+)
+
+// The helpers below implement the reporting lines on top of the lookups of
+// each backend, called inside the transaction of the operation.
+
+// lookupFunc returns the stored employee with the given ID and whether it
+// exists.
+type lookupFunc func(id string) (employee.Employee, bool, error)
+
+// checkManager verifies that managerID may become the manager of id: the
+// manager exists and does not report to id, directly or not.
+func checkManager(id, managerID string, lookup lookupFunc) error {
+	if managerID == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	for current := managerID; current != ""; {
+		if current == id {
+			return ErrManagementCycle
+		}
+		if seen[current] {
+			// A cycle above id, which can only come from a corrupted store
+			return ErrManagementCycle
+		}
+		seen[current] = true
+		e, found, err := lookup(current)
+		if err != nil {
+			return err
+		}
+		if !found {
+			if current == managerID {
+				return ErrManagerNotFound
+			}
+			return nil
+		}
+		current = e.ManagerID
+	}
+	return nil
+}
+
+// collectReports returns the direct reports of id listed by direct, or every
+// employee below id, breadth first, when transitive is set.
+func collectReports(id string, transitive bool, direct func(id string) ([]employee.Employee, error)) ([]employee.Employee, error) {
+	reports, err := direct(id)
+	if err != nil || !transitive {
+		return reports, err
+	}
+	seen := map[string]bool{id: true}
+	for i := 0; i < len(reports); i++ {
+		if seen[reports[i].ID] {
+			continue
+		}
+		seen[reports[i].ID] = true
+		below, err := direct(reports[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, below...)
+	}
+	return reports, nil
+}
+
+// managementChain returns the managers above id, nearest first.
+func managementChain(id string, lookup lookupFunc) ([]employee.Employee, error) {
+	e, found, err := lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errs.NotFound("Employee %s not found", id)
+	}
+	chain := []employee.Employee{}
+	seen := map[string]bool{id: true}
+	for current := e.ManagerID; current != "" && !seen[current]; {
+		seen[current] = true
+		manager, found, err := lookup(current)
+		if err != nil || !found {
+			return chain, err
+		}
+		chain = append(chain, manager)
+		current = manager.ManagerID
+	}
+	return chain, nil
+}
@@ -0,0 +1,92 @@
+package employee
+
+import (
+	"context"
+	"log/slog"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/logging"
+	"time"
+)
+
+// InstrumentedRepository reports the latency and outcome of every operation
+// of the wrapped Repository to observe, and logs it at debug level with the
+// request-scoped logger of its context.
+type InstrumentedRepository struct {
+	next    Repository
+	observe func(operation string, took time.Duration, err error)
+}
+
+func NewInstrumentedRepository(next Repository, observe func(operation string, took time.Duration, err error)) *InstrumentedRepository {
+	return &InstrumentedRepository{next: next, observe: observe}
+}
+
+func (r *InstrumentedRepository) done(ctx context.Context, operation string, start time.Time, err *error) {
+	took := time.Since(start)
+	r.observe(operation, took, *err)
+	attrs := []slog.Attr{
+		slog.String("repository", "employee"),
+		slog.String("operation", operation),
+		slog.Duration("duration", took),
+	}
+	if *err != nil {
+		attrs = append(attrs, slog.String("error", (*err).Error()))
+	}
+	logging.FromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "repository operation", attrs...)
+}
+
+func (r *InstrumentedRepository) CreateEmployee(ctx context.Context, e employee.Employee) (_ *employee.Employee, err error) {
+	defer r.done(ctx, "create", time.Now(), &err)
+	return r.next.CreateEmployee(ctx, e)
+}
+
+func (r *InstrumentedRepository) GetAllEmployees(ctx context.Context) (_ []employee.Employee, err error) {
+	defer r.done(ctx, "get_all", time.Now(), &err)
+	return r.next.GetAllEmployees(ctx)
+}
+
+func (r *InstrumentedRepository) ListEmployees(ctx context.Context, q employee.ListQuery) (_ listing.Page[employee.Employee], err error) {
+	defer r.done(ctx, "list", time.Now(), &err)
+	return r.next.ListEmployees(ctx, q)
+}
+
+func (r *InstrumentedRepository) GetEmployeeByID(ctx context.Context, id string) (_ *employee.Employee, err error) {
+	defer r.done(ctx, "get", time.Now(), &err)
+	return r.next.GetEmployeeByID(ctx, id)
+}
+
+func (r *InstrumentedRepository) UpdateEmployeeByID(ctx context.Context, id string, cond version.Condition, update employee.Employee) (_ *employee.Employee, err error) {
+	defer r.done(ctx, "update", time.Now(), &err)
+	return r.next.UpdateEmployeeByID(ctx, id, cond, update)
+}
+
+func (r *InstrumentedRepository) PatchEmployeeByID(ctx context.Context, id string, cond version.Condition, apply func(employee.Employee) (employee.Employee, error)) (_ *employee.Employee, err error) {
+	defer r.done(ctx, "patch", time.Now(), &err)
+	return r.next.PatchEmployeeByID(ctx, id, cond, apply)
+}
+
+func (r *InstrumentedRepository) DeleteEmployeeByID(ctx context.Context, id string, cond version.Condition) (err error) {
+	defer r.done(ctx, "delete", time.Now(), &err)
+	return r.next.DeleteEmployeeByID(ctx, id, cond)
+}
+
+func (r *InstrumentedRepository) GetAllEmployeesByDepartmentID(ctx context.Context, deptID string) (_ []employee.Employee, err error) {
+	defer r.done(ctx, "get_by_department", time.Now(), &err)
+	return r.next.GetAllEmployeesByDepartmentID(ctx, deptID)
+}
+
+func (r *InstrumentedRepository) GetAllEmployeesByDepartmentTree(ctx context.Context, deptID string) (_ []employee.Employee, err error) {
+	defer r.done(ctx, "get_by_department_tree", time.Now(), &err)
+	return r.next.GetAllEmployeesByDepartmentTree(ctx, deptID)
+}
+
+func (r *InstrumentedRepository) GetReports(ctx context.Context, id string, transitive bool) (_ []employee.Employee, err error) {
+	defer r.done(ctx, "get_reports", time.Now(), &err)
+	return r.next.GetReports(ctx, id, transitive)
+}
+
+func (r *InstrumentedRepository) GetManagementChain(ctx context.Context, id string) (_ []employee.Employee, err error) {
+	defer r.done(ctx, "get_management_chain", time.Now(), &err)
+	return r.next.GetManagementChain(ctx, id)
+}
@@ -0,0 +1,277 @@
+package employee
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/repository/memory"
+)
+
+// MemoryRepository keeps employees in a memory.Store, for tests and for
+// running without a database file. It behaves like BoltRepository.
+type MemoryRepository struct {
+	store *memory.Store
+}
+
+func NewMemoryRepository(store *memory.Store) *MemoryRepository {
+	return &MemoryRepository{store: store}
+}
+
+func (r *MemoryRepository) GetAllEmployees(ctx context.Context) ([]employee.Employee, error) {
+	var employees []employee.Employee
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		for _, e := range tx.Employees {
+			employees = append(employees, e)
+		}
+		return nil
+	})
+	slices.SortFunc(employees, func(a, b employee.Employee) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return employees, errs.Storage(err)
+}
+
+func (r *MemoryRepository) ListEmployees(ctx context.Context, q employee.ListQuery) (listing.Page[employee.Employee], error) {
+	after, err := listing.DecodeCursor(q.Sort, q.After)
+	if err != nil {
+		return listing.Page[employee.Employee]{}, err
+	}
+
+	position := strings.ToLower(q.Position)
+	namePrefix := strings.ToLower(q.NamePrefix)
+	key := func(e employee.Employee) []byte { return []byte(e.ID) }
+	switch q.Sort {
+	case employee.SortByName:
+		key = func(e employee.Employee) []byte { return listing.SortKey(e.Name, e.ID) }
+	case employee.SortByPosition:
+		key = func(e employee.Employee) []byte { return listing.SortKey(e.Position, e.ID) }
+	}
+	match := func(e employee.Employee) bool {
+		return (position == "" || strings.ToLower(e.Position) == position) &&
+			(q.Status == "" || e.Status == q.Status) &&
+			strings.HasPrefix(strings.ToLower(e.Name), namePrefix)
+	}
+
+	var page listing.Page[employee.Employee]
+	err = r.store.View(ctx, func(tx *memory.Tx) error {
+		page, err = memory.List(ctx, tx.Employees, key, after, q.Limit, q.Sort, match)
+		return err
+	})
+	return page, errs.Storage(err)
+}
+
+func (r *MemoryRepository) CreateEmployee(ctx context.Context, e employee.Employee) (*employee.Employee, error) {
+	err := r.store.Update(ctx, func(tx *memory.Tx) error {
+		if !tx.DepartmentExists(e.DepartmentId) {
+			return ErrDepartmentNotFound
+		}
+		if _, exists := tx.Employees[e.ID]; exists {
+			return ErrEmployeeExists
+		}
+		if err := checkManager(e.ID, e.ManagerID, memoryLookup(tx)); err != nil {
+			return err
+		}
+		if err := checkEmail(e.ID, e.Email, memoryEmailLookup(tx)); err != nil {
+			return err
+		}
+		if e.Status == "" {
+			e.Status = employee.Active
+		}
+		e.Version = 1
+		tx.Employees[e.ID] = e
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &e, nil
+}
+
+func (r *MemoryRepository) GetEmployeeByID(ctx context.Context, id string) (*employee.Employee, error) {
+	var emp employee.Employee
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		var ok bool
+		if emp, ok = tx.Employees[id]; !ok {
+			return errs.NotFound("Employee %s not found", id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &emp, nil
+}
+
+func (r *MemoryRepository) UpdateEmployeeByID(ctx context.Context, id string, cond version.Condition, update employee.Employee) (*employee.Employee, error) {
+	return r.PatchEmployeeByID(ctx, id, cond, func(emp employee.Employee) (employee.Employee, error) {
+		emp.Name = update.Name
+		emp.Position = update.Position
+		emp.DepartmentId = update.DepartmentId
+		emp.ManagerID = update.ManagerID
+		emp.Email = update.Email
+		emp.Phone = update.Phone
+		emp.EmploymentType = update.EmploymentType
+		emp.Location = update.Location
+		emp.CostCenter = update.CostCenter
+		// The status and the employment dates are left alone, as they only
+		// change through transitions
+		return emp, nil
+	})
+}
+
+func (r *MemoryRepository) PatchEmployeeByID(ctx context.Context, id string, cond version.Condition, apply func(employee.Employee) (employee.Employee, error)) (*employee.Employee, error) {
+	var patched employee.Employee
+	err := r.store.Update(ctx, func(tx *memory.Tx) error {
+		emp, ok := tx.Employees[id]
+		if !ok {
+			return errs.NotFound("Employee %s not found", id)
+		}
+		if !cond.Holds(emp.Version) {
+			return errs.PreconditionFailed("Employee %s has been modified", id)
+		}
+
+		var err error
+		if patched, err = apply(emp); err != nil {
+			return err
+		}
+		patched.ID = id
+		patched.Version = emp.Version + 1
+
+		if patched.DepartmentId != emp.DepartmentId && !tx.DepartmentExists(patched.DepartmentId) {
+			return ErrDepartmentNotFound
+		}
+		if patched.ManagerID != emp.ManagerID {
+			if err := checkManager(id, patched.ManagerID, memoryLookup(tx)); err != nil {
+				return err
+			}
+		}
+		if patched.Email != emp.Email {
+			if err := checkEmail(id, patched.Email, memoryEmailLookup(tx)); err != nil {
+				return err
+			}
+		}
+		if patched.DepartmentId != emp.DepartmentId {
+			memoryClearHead(tx, emp.DepartmentId, id)
+		}
+		tx.Employees[id] = patched
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &patched, nil
+}
+
+func (r *MemoryRepository) DeleteEmployeeByID(ctx context.Context, id string, cond version.Condition) error {
+	err := r.store.Update(ctx, func(tx *memory.Tx) error {
+		emp, ok := tx.Employees[id]
+		if !ok {
+			return errs.NotFound("Employee %s not found", id)
+		}
+		if !cond.Holds(emp.Version) {
+			return errs.PreconditionFailed("Employee %s has been modified", id)
+		}
+		if len(tx.EmployeeIDsByManager(id)) > 0 {
+			return ErrEmployeeHasReports
+		}
+		memoryClearHead(tx, emp.DepartmentId, id)
+		delete(tx.Employees, id)
+		return nil
+	})
+	return errs.Storage(err)
+}
+
+func (r *MemoryRepository) GetAllEmployeesByDepartmentID(ctx context.Context, deptID string) ([]employee.Employee, error) {
+	var employees []employee.Employee
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		for _, id := range tx.EmployeeIDsByDepartment(deptID) {
+			employees = append(employees, tx.Employees[id])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return employees, nil
+}
+
+func (r *MemoryRepository) GetAllEmployeesByDepartmentTree(ctx context.Context, deptID string) ([]employee.Employee, error) {
+	var employees []employee.Employee
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		for _, id := range tx.DepartmentSubtree(deptID) {
+			for _, empID := range tx.EmployeeIDsByDepartment(id) {
+				employees = append(employees, tx.Employees[empID])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	slices.SortFunc(employees, func(a, b employee.Employee) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return employees, nil
+}
+
+func (r *MemoryRepository) GetReports(ctx context.Context, id string, transitive bool) ([]employee.Employee, error) {
+	var reports []employee.Employee
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		if _, ok := tx.Employees[id]; !ok {
+			return errs.NotFound("Employee %s not found", id)
+		}
+		var err error
+		reports, err = collectReports(id, transitive, func(id string) ([]employee.Employee, error) {
+			reports := []employee.Employee{}
+			for _, reportID := range tx.EmployeeIDsByManager(id) {
+				reports = append(reports, tx.Employees[reportID])
+			}
+			return reports, ctx.Err()
+		})
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return reports, nil
+}
+
+func (r *MemoryRepository) GetManagementChain(ctx context.Context, id string) ([]employee.Employee, error) {
+	var chain []employee.Employee
+	err := r.store.View(ctx, func(tx *memory.Tx) error {
+		var err error
+		chain, err = managementChain(id, memoryLookup(tx))
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return chain, nil
+}
+
+// memoryClearHead removes employeeID as the head of the department deptID,
+// which gets a new version, when it is.
+func memoryClearHead(tx *memory.Tx, deptID, employeeID string) {
+	if dept, ok := tx.Departments[deptID]; ok && dept.HeadID == employeeID {
+		dept.HeadID = ""
+		dept.Version++
+		tx.Departments[deptID] = dept
+	}
+}
+
+func memoryEmailLookup(tx *memory.Tx) emailLookup {
+	return func(email string) (string, error) {
+		return tx.EmployeeIDByEmail(email), nil
+	}
+}
+
+func memoryLookup(tx *memory.Tx) lookupFunc {
+	return func(id string) (employee.Employee, bool, error) {
+		e, ok := tx.Employees[id]
+		return e, ok, nil
+	}
+}
@@ -1,24 +1,59 @@
 package employee
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
+	"context"
 	"go.etcd.io/bbolt"
+	"slices"
+	"strings"
 	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/repository/boltdb"
 )
 
 const (
-	employeeBucket = "Employees"
+	employeeBucket = boltdb.EmployeeBucket
+)
+
+var (
+	// ErrDepartmentNotFound is returned when an employee refers to a department
+	// that is not stored in the Departments bucket.
+	ErrDepartmentNotFound = errs.Validation("Department does not exist")
+	// ErrEmployeeExists is returned when creating an employee whose ID is taken.
+	ErrEmployeeExists = errs.Conflict("Employee already exists")
+	// ErrManagerNotFound is returned when an employee refers to a manager
+	// that is not stored.
+	ErrManagerNotFound = errs.Validation("Manager does not exist")
+	// ErrManagementCycle is returned when a manager would end up reporting,
+	// directly or not, to the employee they manage.
+	ErrManagementCycle = errs.Validation("Manager would create a reporting cycle")
+	// ErrEmployeeHasReports is returned when deleting an employee who still
+	// manages other employees.
+	ErrEmployeeHasReports = errs.Conflict("Employee has direct reports")
+	// ErrEmailTaken is returned when an employee uses the email of another
+	// one.
+	ErrEmailTaken = errs.Conflict("Email is already used by another employee")
 )
 
 type Repository interface {
-	CreateEmployee(e employee.Employee) error
-	GetAllEmployees() ([]employee.Employee, error)
-	GetEmployeeByID(id string) (*employee.Employee, error)
-	UpdateEmployeeByID(id string, update employee.Employee) error
-	DeleteEmployeeByID(id string) error
-	GetAllEmployeesByDepartmentID(deptID string) ([]employee.Employee, error)
+	CreateEmployee(ctx context.Context, e employee.Employee) (*employee.Employee, error)
+	GetAllEmployees(ctx context.Context) ([]employee.Employee, error)
+	ListEmployees(ctx context.Context, q employee.ListQuery) (listing.Page[employee.Employee], error)
+	GetEmployeeByID(ctx context.Context, id string) (*employee.Employee, error)
+	UpdateEmployeeByID(ctx context.Context, id string, cond version.Condition, update employee.Employee) (*employee.Employee, error)
+	PatchEmployeeByID(ctx context.Context, id string, cond version.Condition, apply func(employee.Employee) (employee.Employee, error)) (*employee.Employee, error)
+	DeleteEmployeeByID(ctx context.Context, id string, cond version.Condition) error
+	GetAllEmployeesByDepartmentID(ctx context.Context, deptID string) ([]employee.Employee, error)
+	// GetAllEmployeesByDepartmentTree returns, in ID order, the employees of
+	// deptID and of every department below it.
+	GetAllEmployeesByDepartmentTree(ctx context.Context, deptID string) ([]employee.Employee, error)
+	// GetReports returns the employees reporting directly to id, or with
+	// transitive every employee below id, level by level in ID order.
+	GetReports(ctx context.Context, id string, transitive bool) ([]employee.Employee, error)
+	// GetManagementChain returns the managers above id, from the direct
+	// manager up to the top of the organization.
+	GetManagementChain(ctx context.Context, id string) ([]employee.Employee, error)
 }
 
 type BoltRepository struct {
@@ -29,102 +64,233 @@ func NewBoltRepository(db *bbolt.DB) *BoltRepository {
 	return &BoltRepository{db: db}
 }
 
-func (r *BoltRepository) GetAllEmployees() ([]employee.Employee, error) {
+func (r *BoltRepository) GetAllEmployees(ctx context.Context) ([]employee.Employee, error) {
 	var employees []employee.Employee
-	err := r.db.View(func(tx *bbolt.Tx) error {
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(employeeBucket))
 		if b == nil {
 			return nil // Nenhum bucket, sem dados
 		}
 		return b.ForEach(func(k, v []byte) error {
-			var emp employee.Employee
-			if err := json.Unmarshal(v, &emp); err != nil {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			emp, err := boltdb.DecodeEmployee(v)
+			if err != nil {
 				return err
 			}
 			employees = append(employees, emp)
 			return nil
 		})
 	})
-	return employees, err
+	return employees, errs.Storage(err)
+}
+
+// ListEmployees returns one page of employees, walking the sort index with a
+// bbolt cursor.
+func (r *BoltRepository) ListEmployees(ctx context.Context, q employee.ListQuery) (listing.Page[employee.Employee], error) {
+	after, err := listing.DecodeCursor(q.Sort, q.After)
+	if err != nil {
+		return listing.Page[employee.Employee]{}, err
+	}
+
+	position := strings.ToLower(q.Position)
+	namePrefix := strings.ToLower(q.NamePrefix)
+	rng := boltdb.Range{After: after, Limit: q.Limit}
+	switch q.Sort {
+	case employee.SortByName:
+		rng.Index = boltdb.EmployeeNameIndexBucket
+		rng.Prefix = []byte(namePrefix)
+	case employee.SortByPosition:
+		rng.Index = boltdb.EmployeePositionIndexBucket
+		if position != "" {
+			rng.Prefix = listing.SortPrefix(position)
+		}
+	}
+	match := func(e employee.Employee) bool {
+		return (position == "" || strings.ToLower(e.Position) == position) &&
+			(q.Status == "" || e.Status == q.Status) &&
+			strings.HasPrefix(strings.ToLower(e.Name), namePrefix)
+	}
+
+	var page listing.Page[employee.Employee]
+	err = boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
+		page, err = boltdb.List(ctx, tx, employeeBucket, rng, q.Sort, boltdb.DecodeEmployee, match)
+		return err
+	})
+	return page, errs.Storage(err)
 }
 
-func (r *BoltRepository) CreateEmployee(e employee.Employee) error {
-	return r.db.Update(func(tx *bbolt.Tx) error {
+func (r *BoltRepository) CreateEmployee(ctx context.Context, e employee.Employee) (*employee.Employee, error) {
+	err := boltdb.Update(ctx, r.db, func(tx *bbolt.Tx) error {
+		if !boltdb.DepartmentExists(tx, e.DepartmentId) {
+			return ErrDepartmentNotFound
+		}
 		b, err := tx.CreateBucketIfNotExists([]byte(employeeBucket))
 		if err != nil {
 			return err
 		}
-		encoded, err := json.Marshal(e)
+		if exists := b.Get([]byte(e.ID)); exists != nil {
+			return ErrEmployeeExists
+		}
+		if err := checkManager(e.ID, e.ManagerID, boltLookup(tx)); err != nil {
+			return err
+		}
+		if err := checkEmail(e.ID, e.Email, boltEmailLookup(tx)); err != nil {
+			return err
+		}
+		if e.Status == "" {
+			e.Status = employee.Active
+		}
+		e.Version = 1
+		encoded, err := boltdb.EncodeEmployee(e)
 		if err != nil {
 			return err
 		}
-		return b.Put([]byte(e.ID), encoded)
+		if err := b.Put([]byte(e.ID), encoded); err != nil {
+			return err
+		}
+		return boltdb.IndexEmployee(tx, e)
 	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &e, nil
 }
 
-func (r *BoltRepository) GetEmployeeByID(id string) (*employee.Employee, error) {
+func (r *BoltRepository) GetEmployeeByID(ctx context.Context, id string) (*employee.Employee, error) {
 	var employee *employee.Employee
-	err := r.db.View(func(tx *bbolt.Tx) error {
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(employeeBucket))
 		if b == nil {
-			return errors.New("Employee bucket does not exist")
+			return errs.NotFound("Employee %s not found", id)
 		}
 		v := b.Get([]byte(id)) // Convertendo ID int para string para busca
 		if v == nil {
-			return errors.New("Employee not found")
+			return errs.NotFound("Employee %s not found", id)
 		}
-		return json.Unmarshal(v, &employee)
+		decoded, err := boltdb.DecodeEmployee(v)
+		employee = &decoded
+		return err
 	})
 	if err != nil {
-		return nil, err
+		return nil, errs.Storage(err)
 	}
 	return employee, nil
 } // Implementar os outros métodos CRUD semelhantemente
 
-func (r *BoltRepository) UpdateEmployeeByID(id string, update employee.Employee) error {
-	return r.db.Update(func(tx *bbolt.Tx) error {
+func (r *BoltRepository) UpdateEmployeeByID(ctx context.Context, id string, cond version.Condition, update employee.Employee) (*employee.Employee, error) {
+	return r.PatchEmployeeByID(ctx, id, cond, func(emp employee.Employee) (employee.Employee, error) {
+		// Updating the employee with new data
+		emp.Name = update.Name
+		emp.Position = update.Position
+		emp.DepartmentId = update.DepartmentId
+		emp.ManagerID = update.ManagerID
+		emp.Email = update.Email
+		emp.Phone = update.Phone
+		emp.EmploymentType = update.EmploymentType
+		emp.Location = update.Location
+		emp.CostCenter = update.CostCenter
+		// The status and the employment dates are left alone, as they only
+		// change through transitions
+		return emp, nil
+	})
+}
+
+// PatchEmployeeByID replaces the employee with the result of apply, called
+// with the stored employee inside the update transaction.
+func (r *BoltRepository) PatchEmployeeByID(ctx context.Context, id string, cond version.Condition, apply func(employee.Employee) (employee.Employee, error)) (*employee.Employee, error) {
+	var patched employee.Employee
+	err := boltdb.Update(ctx, r.db, func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(employeeBucket))
 		if b == nil {
-			return errors.New("Employee bucket does not exist")
+			return errs.NotFound("Employee %s not found", id)
 		}
 
 		current := b.Get([]byte(id))
 		if current == nil {
-			return errors.New("Employee not found")
+			return errs.NotFound("Employee %s not found", id)
 		}
 
-		// Optionally, you might want to unmarshal the current employee data
-		// and apply only specific changes or validate the changes
-		var emp employee.Employee
-		if err := json.Unmarshal(current, &emp); err != nil {
+		emp, err := boltdb.DecodeEmployee(current)
+		if err != nil {
 			return err
 		}
 
-		// Updating the employee with new data
-		emp.Name = update.Name
-		emp.Position = update.Position
-		emp.DepartmentId = update.DepartmentId
+		if !cond.Holds(emp.Version) {
+			return errs.PreconditionFailed("Employee %s has been modified", id)
+		}
+
+		if patched, err = apply(emp); err != nil {
+			return err
+		}
+		patched.ID = id
+		patched.Version = emp.Version + 1
 
-		// Marshal the updated employee back to JSON
-		updated, err := json.Marshal(emp)
+		if patched.DepartmentId != emp.DepartmentId && !boltdb.DepartmentExists(tx, patched.DepartmentId) {
+			return ErrDepartmentNotFound
+		}
+		if patched.ManagerID != emp.ManagerID {
+			if err := checkManager(id, patched.ManagerID, boltLookup(tx)); err != nil {
+				return err
+			}
+		}
+		if patched.Email != emp.Email {
+			if err := checkEmail(id, patched.Email, boltEmailLookup(tx)); err != nil {
+				return err
+			}
+		}
+		if patched.DepartmentId != emp.DepartmentId {
+			if err := boltClearHead(tx, emp.DepartmentId, id); err != nil {
+				return err
+			}
+		}
+		if err := boltdb.UnindexEmployee(tx, emp); err != nil {
+			return err
+		}
+
+		// Encode the updated employee
+		updated, err := boltdb.EncodeEmployee(patched)
 		if err != nil {
 			return err
 		}
 
 		// Save the updated employee back to the database
-		return b.Put([]byte(id), updated)
+		if err := b.Put([]byte(id), updated); err != nil {
+			return err
+		}
+		return boltdb.IndexEmployee(tx, patched)
 	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &patched, nil
 }
 
-func (r *BoltRepository) DeleteEmployeeByID(id string) error {
-	return r.db.Update(func(tx *bbolt.Tx) error {
+func (r *BoltRepository) DeleteEmployeeByID(ctx context.Context, id string, cond version.Condition) error {
+	err := boltdb.Update(ctx, r.db, func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(employeeBucket))
 		if b == nil {
-			return errors.New("Employee bucket does not exist")
+			return errs.NotFound("Employee %s not found", id)
 		}
 
-		if exists := b.Get([]byte(id)); exists == nil {
-			return errors.New("Employee not found")
+		current := b.Get([]byte(id))
+		if current == nil {
+			return errs.NotFound("Employee %s not found", id)
+		}
+
+		emp, err := boltdb.DecodeEmployee(current)
+		if err != nil {
+			return err
+		}
+		if !cond.Holds(emp.Version) {
+			return errs.PreconditionFailed("Employee %s has been modified", id)
+		}
+		if len(boltdb.EmployeeIDsByManager(tx, id)) > 0 {
+			return ErrEmployeeHasReports
+		}
+		if err := boltClearHead(tx, emp.DepartmentId, id); err != nil {
+			return err
 		}
 
 		// Delete the employee
@@ -132,38 +298,171 @@ func (r *BoltRepository) DeleteEmployeeByID(id string) error {
 			return err
 		}
 
-		return nil
+		return boltdb.UnindexEmployee(tx, emp)
 	})
+	return errs.Storage(err)
 }
 
-func (r *BoltRepository) GetAllEmployeesByDepartmentID(deptID string) ([]employee.Employee, error) {
+func (r *BoltRepository) GetAllEmployeesByDepartmentID(ctx context.Context, deptID string) ([]employee.Employee, error) {
 	var employees []employee.Employee
 
-	err := r.db.View(func(tx *bbolt.Tx) error {
-		// Acessa o bucket específico do departamento
-		b := tx.Bucket(getDepartmentBucketName(deptID))
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(employeeBucket))
 		if b == nil {
-			return nil // Nenhum funcionário neste departamento
+			return nil // Nenhum funcionário cadastrado
 		}
 
-		// Itera apenas sobre os empregados neste bucket de departamento
-		return b.ForEach(func(k, v []byte) error {
-			var emp employee.Employee
-			if err := json.Unmarshal(v, &emp); err != nil {
+		// Resolve os empregados do departamento através do índice
+		for _, id := range boltdb.EmployeeIDsByDepartment(tx, deptID) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			v := b.Get([]byte(id))
+			if v == nil {
+				continue
+			}
+			emp, err := boltdb.DecodeEmployee(v)
+			if err != nil {
 				return err
 			}
 			employees = append(employees, emp)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+
+	return employees, nil
+}
+
+// GetAllEmployeesByDepartmentTree resolves the employees of deptID and of
+// every department below it through the department and parent indexes.
+func (r *BoltRepository) GetAllEmployeesByDepartmentTree(ctx context.Context, deptID string) ([]employee.Employee, error) {
+	var employees []employee.Employee
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(employeeBucket))
+		if b == nil {
 			return nil
+		}
+		for _, id := range boltdb.DepartmentSubtree(tx, deptID) {
+			for _, empID := range boltdb.EmployeeIDsByDepartment(tx, id) {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				v := b.Get([]byte(empID))
+				if v == nil {
+					continue
+				}
+				emp, err := boltdb.DecodeEmployee(v)
+				if err != nil {
+					return err
+				}
+				employees = append(employees, emp)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	slices.SortFunc(employees, func(a, b employee.Employee) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return employees, nil
+}
+
+// GetReports resolves the reports of id through the manager index.
+func (r *BoltRepository) GetReports(ctx context.Context, id string, transitive bool) ([]employee.Employee, error) {
+	var reports []employee.Employee
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
+		lookup := boltLookup(tx)
+		_, found, err := lookup(id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errs.NotFound("Employee %s not found", id)
+		}
+		reports, err = collectReports(id, transitive, func(id string) ([]employee.Employee, error) {
+			reports := []employee.Employee{}
+			for _, reportID := range boltdb.EmployeeIDsByManager(tx, id) {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				report, found, err := lookup(reportID)
+				if err != nil {
+					return nil, err
+				}
+				if found {
+					reports = append(reports, report)
+				}
+			}
+			return reports, nil
 		})
+		return err
 	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return reports, nil
+}
 
+func (r *BoltRepository) GetManagementChain(ctx context.Context, id string) ([]employee.Employee, error) {
+	var chain []employee.Employee
+	err := boltdb.View(ctx, r.db, func(tx *bbolt.Tx) error {
+		var err error
+		chain, err = managementChain(id, boltLookup(tx))
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, errs.Storage(err)
 	}
+	return chain, nil
+}
 
-	return employees, nil
+// boltClearHead removes employeeID as the head of the department deptID,
+// which gets a new version, when it is.
+func boltClearHead(tx *bbolt.Tx, deptID, employeeID string) error {
+	b := tx.Bucket([]byte(boltdb.DepartmentBucket))
+	if b == nil {
+		return nil
+	}
+	current := b.Get([]byte(deptID))
+	if current == nil {
+		return nil
+	}
+	dept, err := boltdb.DecodeDepartment(current)
+	if err != nil || dept.HeadID != employeeID {
+		return err
+	}
+	dept.HeadID = ""
+	dept.Version++
+	updated, err := boltdb.EncodeDepartment(dept)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(deptID), updated)
 }
 
-func getDepartmentBucketName(deptID string) []byte {
-	return []byte(fmt.Sprintf("Department_%d", deptID))
+func boltEmailLookup(tx *bbolt.Tx) emailLookup {
+	return func(email string) (string, error) {
+		return boltdb.EmployeeIDByEmail(tx, email), nil
+	}
+}
+
+func boltLookup(tx *bbolt.Tx) lookupFunc {
+	return func(id string) (employee.Employee, bool, error) {
+		b := tx.Bucket([]byte(employeeBucket))
+		if b == nil {
+			return employee.Employee{}, false, nil
+		}
+		v := b.Get([]byte(id))
+		if v == nil {
+			return employee.Employee{}, false, nil
+		}
+		e, err := boltdb.DecodeEmployee(v)
+		return e, err == nil, err
+	}
 }
@@ -0,0 +1,92 @@
+package employee_test
+
+import (
+	"context"
+	"go.etcd.io/bbolt"
+	"path/filepath"
+	"slices"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/repository/boltdb"
+	repositoryDept "template-golang/internal/repository/department"
+	repositoryEmployee "template-golang/internal/repository/employee"
+	"testing"
+)
+
+// indexed returns the IDs the department index lists under deptID.
+func indexed(t *testing.T, db *bbolt.DB, deptID string) []string {
+	t.Helper()
+	var ids []string
+	if err := db.View(func(tx *bbolt.Tx) error {
+		ids = boltdb.EmployeeIDsByDepartment(tx, deptID)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func idsByDepartment(t *testing.T, repo *repositoryEmployee.BoltRepository, deptID string) []string {
+	t.Helper()
+	employees, err := repo.GetAllEmployeesByDepartmentID(context.Background(), deptID)
+	if err != nil {
+		t.Fatalf("employees of %s: %v", deptID, err)
+	}
+	var ids []string
+	for _, e := range employees {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestBoltDepartmentIndex(t *testing.T) {
+	ctx := context.Background()
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := boltdb.EnsureBuckets(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := boltdb.EnsureIndexes(db); err != nil {
+		t.Fatal(err)
+	}
+	depts := repositoryDept.NewBoltRepository(db)
+	for _, id := range []string{"eng", "ops"} {
+		if _, err := depts.CreateDepartment(ctx, department.Department{ID: id, Name: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := repositoryEmployee.NewBoltRepository(db)
+	for _, id := range []string{"e1", "e2"} {
+		if _, err := repo.CreateEmployee(ctx, employee.Employee{ID: id, Name: "Ada", Position: "Engineer", DepartmentId: "eng"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	update := employee.Employee{ID: "e1", Name: "Ada", Position: "Engineer", DepartmentId: "ops"}
+	if _, err := repo.UpdateEmployeeByID(ctx, "e1", version.Any(), update); err != nil {
+		t.Fatal(err)
+	}
+	if got := idsByDepartment(t, repo, "eng"); !slices.Equal(got, []string{"e2"}) {
+		t.Errorf("eng after the move = %v, want [e2]", got)
+	}
+	if got := idsByDepartment(t, repo, "ops"); !slices.Equal(got, []string{"e1"}) {
+		t.Errorf("ops after the move = %v, want [e1]", got)
+	}
+
+	if err := repo.DeleteEmployeeByID(ctx, "e1", version.Any()); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexed(t, db, "ops"); len(got) != 0 {
+		t.Errorf("index of ops after the delete = %v, want it empty", got)
+	}
+	if got := idsByDepartment(t, repo, "ops"); len(got) != 0 {
+		t.Errorf("ops after the delete = %v, want no employees", got)
+	}
+	if got := indexed(t, db, "eng"); !slices.Equal(got, []string{"e2"}) {
+		t.Errorf("index of eng = %v, want [e2]", got)
+	}
+}
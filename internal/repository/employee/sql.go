@@ -0,0 +1,404 @@
+package employee
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/repository/sqldb"
+)
+
+const employeeColumns = `id, name, position, department_id, COALESCE(manager_id, ''),
+	COALESCE(email, ''), COALESCE(phone, ''), COALESCE(hire_date, ''), COALESCE(termination_date, ''),
+	COALESCE(employment_type, ''), COALESCE(location, ''), COALESCE(cost_center, ''),
+	status, COALESCE(status_date, ''), COALESCE(status_reason, ''), version`
+
+// SQLRepository keeps employees in the employees table of a SQL database.
+// It behaves like BoltRepository.
+type SQLRepository struct {
+	db      *sql.DB
+	dialect sqldb.Dialect
+}
+
+func NewSQLRepository(db *sql.DB, d sqldb.Dialect) *SQLRepository {
+	return &SQLRepository{db: db, dialect: d}
+}
+
+func scanEmployee(row interface{ Scan(...any) error }) (employee.Employee, error) {
+	var e employee.Employee
+	err := row.Scan(&e.ID, &e.Name, &e.Position, &e.DepartmentId, &e.ManagerID,
+		&e.Email, &e.Phone, &e.HireDate, &e.TerminationDate,
+		&e.EmploymentType, &e.Location, &e.CostCenter,
+		&e.Status, &e.StatusDate, &e.StatusReason, &e.Version)
+	return e, err
+}
+
+func (r *SQLRepository) GetAllEmployees(ctx context.Context) ([]employee.Employee, error) {
+	var employees []employee.Employee
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		var err error
+		employees, err = queryEmployees(ctx, tx, `SELECT `+employeeColumns+` FROM employees ORDER BY id`)
+		return err
+	})
+	return employees, errs.Storage(err)
+}
+
+// ListEmployees returns one page of employees, seeking past the cursor on
+// the index of the sort column.
+func (r *SQLRepository) ListEmployees(ctx context.Context, q employee.ListQuery) (listing.Page[employee.Employee], error) {
+	after, err := listing.DecodeCursor(q.Sort, q.After)
+	if err != nil {
+		return listing.Page[employee.Employee]{}, err
+	}
+
+	l := sqldb.Listing{Table: "employees", Columns: employeeColumns}
+	key := func(e employee.Employee) []byte { return []byte(e.ID) }
+	switch q.Sort {
+	case employee.SortByName:
+		l.SortColumn = "name_key"
+		key = func(e employee.Employee) []byte { return listing.SortKey(e.Name, e.ID) }
+	case employee.SortByPosition:
+		l.SortColumn = "position_key"
+		key = func(e employee.Employee) []byte { return listing.SortKey(e.Position, e.ID) }
+	}
+	if q.Position != "" {
+		l.Where = append(l.Where, "position_key = ?")
+		l.Args = append(l.Args, strings.ToLower(q.Position))
+	}
+	if q.Status != "" {
+		l.Where = append(l.Where, "status = ?")
+		l.Args = append(l.Args, q.Status)
+	}
+	if q.NamePrefix != "" {
+		l.Where = append(l.Where, `name_key LIKE ? ESCAPE '\'`)
+		l.Args = append(l.Args, sqldb.PrefixPattern(strings.ToLower(q.NamePrefix)))
+	}
+
+	var page listing.Page[employee.Employee]
+	err = sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		page, err = sqldb.List(ctx, tx, l, q.Sort, after, q.Limit, func(rows *sql.Rows) (employee.Employee, error) {
+			return scanEmployee(rows)
+		}, key)
+		return err
+	})
+	return page, errs.Storage(err)
+}
+
+func (r *SQLRepository) CreateEmployee(ctx context.Context, e employee.Employee) (*employee.Employee, error) {
+	err := sqldb.Update(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		exists, err := sqldb.DepartmentExists(ctx, tx, e.DepartmentId)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrDepartmentNotFound
+		}
+		if err := r.checkManager(ctx, tx, e.ID, e.ManagerID); err != nil {
+			return err
+		}
+		if err := checkEmail(e.ID, e.Email, sqlEmailLookup(ctx, tx)); err != nil {
+			return err
+		}
+		if e.Status == "" {
+			e.Status = employee.Active
+		}
+		e.Version = 1
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO employees (id, name, name_key, position, position_key, department_id, manager_id,
+				email, phone, hire_date, termination_date, employment_type, location, cost_center,
+				status, status_date, status_reason, version)
+			VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''),
+				?, NULLIF(?, ''), NULLIF(?, ''), ?)`,
+			e.ID, e.Name, strings.ToLower(e.Name), e.Position, strings.ToLower(e.Position), e.DepartmentId, e.ManagerID,
+			e.Email, e.Phone, e.HireDate, e.TerminationDate, e.EmploymentType, e.Location, e.CostCenter,
+			e.Status, e.StatusDate, e.StatusReason, e.Version)
+		switch {
+		case r.dialect.IsUniqueViolation(err):
+			// The email was checked above, so the ID is taken unless a
+			// concurrent transaction took the email in between.
+			return ErrEmployeeExists
+		case r.dialect.IsForeignKeyViolation(err):
+			// The department was deleted by a concurrent transaction
+			return ErrDepartmentNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &e, nil
+}
+
+func (r *SQLRepository) GetEmployeeByID(ctx context.Context, id string) (*employee.Employee, error) {
+	var emp employee.Employee
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		var err error
+		emp, err = scanEmployee(tx.QueryRowContext(ctx, `SELECT `+employeeColumns+` FROM employees WHERE id = ?`, id))
+		if err == sql.ErrNoRows {
+			return errs.NotFound("Employee %s not found", id)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &emp, nil
+}
+
+func (r *SQLRepository) UpdateEmployeeByID(ctx context.Context, id string, cond version.Condition, update employee.Employee) (*employee.Employee, error) {
+	return r.PatchEmployeeByID(ctx, id, cond, func(emp employee.Employee) (employee.Employee, error) {
+		emp.Name = update.Name
+		emp.Position = update.Position
+		emp.DepartmentId = update.DepartmentId
+		emp.ManagerID = update.ManagerID
+		emp.Email = update.Email
+		emp.Phone = update.Phone
+		emp.EmploymentType = update.EmploymentType
+		emp.Location = update.Location
+		emp.CostCenter = update.CostCenter
+		// The status and the employment dates are left alone, as they only
+		// change through transitions
+		return emp, nil
+	})
+}
+
+// PatchEmployeeByID replaces the employee with the result of apply, called
+// with the stored employee inside the update transaction.
+func (r *SQLRepository) PatchEmployeeByID(ctx context.Context, id string, cond version.Condition, apply func(employee.Employee) (employee.Employee, error)) (*employee.Employee, error) {
+	var patched employee.Employee
+	err := sqldb.Update(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		emp, err := r.current(ctx, tx, id, cond, r.dialect.ForNoKeyUpdate)
+		if err != nil {
+			return err
+		}
+
+		if patched, err = apply(emp); err != nil {
+			return err
+		}
+		patched.ID = id
+		patched.Version = emp.Version + 1
+
+		if patched.DepartmentId != emp.DepartmentId {
+			exists, err := sqldb.DepartmentExists(ctx, tx, patched.DepartmentId)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrDepartmentNotFound
+			}
+		}
+		if patched.ManagerID != emp.ManagerID {
+			if err := r.checkManager(ctx, tx, id, patched.ManagerID); err != nil {
+				return err
+			}
+		}
+		if patched.Email != emp.Email {
+			if err := checkEmail(id, patched.Email, sqlEmailLookup(ctx, tx)); err != nil {
+				return err
+			}
+		}
+		if patched.DepartmentId != emp.DepartmentId {
+			if err := sqlClearHead(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE employees SET name = ?, name_key = ?, position = ?, position_key = ?, department_id = ?, manager_id = NULLIF(?, ''),
+				email = NULLIF(?, ''), phone = NULLIF(?, ''), hire_date = NULLIF(?, ''), termination_date = NULLIF(?, ''),
+				employment_type = NULLIF(?, ''), location = NULLIF(?, ''), cost_center = NULLIF(?, ''),
+				status = ?, status_date = NULLIF(?, ''), status_reason = NULLIF(?, ''), version = ?
+			WHERE id = ?`,
+			patched.Name, strings.ToLower(patched.Name), patched.Position, strings.ToLower(patched.Position), patched.DepartmentId, patched.ManagerID,
+			patched.Email, patched.Phone, patched.HireDate, patched.TerminationDate,
+			patched.EmploymentType, patched.Location, patched.CostCenter,
+			patched.Status, patched.StatusDate, patched.StatusReason, patched.Version, id)
+		switch {
+		case r.dialect.IsForeignKeyViolation(err):
+			return ErrDepartmentNotFound
+		case r.dialect.IsUniqueViolation(err):
+			// The email was taken by a concurrent transaction
+			return ErrEmailTaken
+		}
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return &patched, nil
+}
+
+func (r *SQLRepository) DeleteEmployeeByID(ctx context.Context, id string, cond version.Condition) error {
+	err := sqldb.Update(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		if _, err := r.current(ctx, tx, id, cond, r.dialect.ForUpdate); err != nil {
+			return err
+		}
+		hasReports, err := sqldb.Exists(ctx, tx, `SELECT 1 FROM employees WHERE manager_id = ? LIMIT 1`, id)
+		if err != nil {
+			return err
+		}
+		if hasReports {
+			return ErrEmployeeHasReports
+		}
+		if err := sqlClearHead(ctx, tx, id); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM employees WHERE id = ?`, id)
+		if r.dialect.IsForeignKeyViolation(err) {
+			return ErrEmployeeHasReports
+		}
+		return err
+	})
+	return errs.Storage(err)
+}
+
+func (r *SQLRepository) GetAllEmployeesByDepartmentID(ctx context.Context, deptID string) ([]employee.Employee, error) {
+	var employees []employee.Employee
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		var err error
+		employees, err = queryEmployees(ctx, tx, `SELECT `+employeeColumns+` FROM employees WHERE department_id = ? ORDER BY id`, deptID)
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return employees, nil
+}
+
+func (r *SQLRepository) GetAllEmployeesByDepartmentTree(ctx context.Context, deptID string) ([]employee.Employee, error) {
+	var employees []employee.Employee
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		var err error
+		employees, err = queryEmployees(ctx, tx,
+			`WITH RECURSIVE subtree (id) AS (
+				SELECT id FROM departments WHERE id = ?
+				UNION
+				SELECT d.id FROM departments d JOIN subtree s ON d.parent_id = s.id
+			)
+			SELECT `+employeeColumns+` FROM employees WHERE department_id IN (SELECT id FROM subtree) ORDER BY id`,
+			deptID)
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return employees, nil
+}
+
+// current reads the employee about to be modified, locking its row with
+// lock, and checks cond against its version.
+func (r *SQLRepository) current(ctx context.Context, tx *sqldb.Tx, id string, cond version.Condition, lock string) (employee.Employee, error) {
+	emp, err := scanEmployee(tx.QueryRowContext(ctx, `SELECT `+employeeColumns+` FROM employees WHERE id = ?`+lock, id))
+	if err == sql.ErrNoRows {
+		return emp, errs.NotFound("Employee %s not found", id)
+	}
+	if err != nil {
+		return emp, err
+	}
+	if !cond.Holds(emp.Version) {
+		return emp, errs.PreconditionFailed("Employee %s has been modified", id)
+	}
+	return emp, nil
+}
+
+func (r *SQLRepository) GetReports(ctx context.Context, id string, transitive bool) ([]employee.Employee, error) {
+	var reports []employee.Employee
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		_, found, err := sqlLookup(ctx, tx)(id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errs.NotFound("Employee %s not found", id)
+		}
+		reports, err = collectReports(id, transitive, func(id string) ([]employee.Employee, error) {
+			reports, err := queryEmployees(ctx, tx, `SELECT `+employeeColumns+` FROM employees WHERE manager_id = ? ORDER BY id`, id)
+			if reports == nil {
+				reports = []employee.Employee{}
+			}
+			return reports, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return reports, nil
+}
+
+func (r *SQLRepository) GetManagementChain(ctx context.Context, id string) ([]employee.Employee, error) {
+	var chain []employee.Employee
+	err := sqldb.View(ctx, r.db, r.dialect, func(tx *sqldb.Tx) error {
+		var err error
+		chain, err = managementChain(id, sqlLookup(ctx, tx))
+		return err
+	})
+	if err != nil {
+		return nil, errs.Storage(err)
+	}
+	return chain, nil
+}
+
+// checkManager holds the hierarchy lock while checking the reporting lines,
+// and locks the row of the manager so it stays until the transaction ends.
+func (r *SQLRepository) checkManager(ctx context.Context, tx *sqldb.Tx, id, managerID string) error {
+	if managerID == "" {
+		return nil
+	}
+	if r.dialect.HierarchyLock != "" {
+		if _, err := tx.ExecContext(ctx, r.dialect.HierarchyLock); err != nil {
+			return err
+		}
+	}
+	if _, err := sqldb.Exists(ctx, tx, `SELECT 1 FROM employees WHERE id = ?`+r.dialect.ForShare, managerID); err != nil {
+		return err
+	}
+	return checkManager(id, managerID, sqlLookup(ctx, tx))
+}
+
+// sqlClearHead removes employeeID as the head of its department, which gets a
+// new version, when it is.
+func sqlClearHead(ctx context.Context, tx *sqldb.Tx, employeeID string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE departments SET head_id = NULL, version = version + 1 WHERE head_id = ?`, employeeID)
+	return err
+}
+
+func sqlEmailLookup(ctx context.Context, tx *sqldb.Tx) emailLookup {
+	return func(email string) (string, error) {
+		var id string
+		err := tx.QueryRowContext(ctx, `SELECT id FROM employees WHERE email = ?`, email).Scan(&id)
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return id, err
+	}
+}
+
+func sqlLookup(ctx context.Context, tx *sqldb.Tx) lookupFunc {
+	return func(id string) (employee.Employee, bool, error) {
+		e, err := scanEmployee(tx.QueryRowContext(ctx, `SELECT `+employeeColumns+` FROM employees WHERE id = ?`, id))
+		if err == sql.ErrNoRows {
+			return e, false, nil
+		}
+		return e, err == nil, err
+	}
+}
+
+func queryEmployees(ctx context.Context, tx *sqldb.Tx, query string, args ...any) ([]employee.Employee, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var employees []employee.Employee
+	for rows.Next() {
+		e, err := scanEmployee(rows)
+		if err != nil {
+			return nil, err
+		}
+		employees = append(employees, e)
+	}
+	return employees, rows.Err()
+}
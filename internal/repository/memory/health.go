@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"template-golang/internal/health"
+)
+
+// Health reports on a Store for the health endpoints.
+type Health struct {
+	store *Store
+}
+
+func NewHealth(store *Store) *Health {
+	return &Health{store: store}
+}
+
+// Ready always succeeds: a Store is usable as soon as it exists.
+func (h *Health) Ready() error {
+	return nil
+}
+
+func (h *Health) Storage() (health.Storage, error) {
+	h.store.mu.RLock()
+	defer h.store.mu.RUnlock()
+	return health.Storage{
+		Backend: "memory",
+		Keys: map[string]int{
+			"Employees":   len(h.store.tx.Employees),
+			"Departments": len(h.store.tx.Departments),
+		},
+	}, nil
+}
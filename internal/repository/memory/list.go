@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"template-golang/internal/domain/listing"
+)
+
+// List returns the page of the records accepted by match whose sort key
+// follows after, ordered by key. Keys are built like the bbolt sort indexes so
+// both backends page identically. Every accepted record counts towards the
+// page total.
+func List[T any](ctx context.Context, records map[string]T, key func(T) []byte, after []byte, limit int, sort string, match func(T) bool) (listing.Page[T], error) {
+	type entry struct {
+		key  []byte
+		item T
+	}
+	page := listing.Page[T]{Items: []T{}}
+	var entries []entry
+	for _, item := range records {
+		if err := ctx.Err(); err != nil {
+			return page, err
+		}
+		if match != nil && !match(item) {
+			continue
+		}
+		page.Total++
+		if k := key(item); after == nil || bytes.Compare(k, after) > 0 {
+			entries = append(entries, entry{key: k, item: item})
+		}
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return bytes.Compare(a.key, b.key)
+	})
+
+	var last []byte
+	for _, e := range entries {
+		if len(page.Items) == limit {
+			page.NextCursor = listing.EncodeCursor(sort, last)
+			break
+		}
+		page.Items = append(page.Items, e.item)
+		last = e.key
+	}
+	return page, nil
+}
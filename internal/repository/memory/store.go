@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+)
+
+// Store holds the records of the in-memory repositories. Employee and
+// department repositories built on the same Store see each other's records,
+// the way bbolt repositories share a database file.
+type Store struct {
+	mu sync.RWMutex
+	tx Tx
+}
+
+// Tx gives access to the records of a Store while its lock is held. Records
+// are kept by value so callers never share them with the store.
+type Tx struct {
+	Employees   map[string]employee.Employee
+	Departments map[string]department.Department
+}
+
+func NewStore() *Store {
+	return &Store{tx: Tx{
+		Employees:   map[string]employee.Employee{},
+		Departments: map[string]department.Department{},
+	}}
+}
+
+// View runs fn holding the read lock unless ctx is already done.
+func (s *Store) View(ctx context.Context, fn func(*Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&s.tx)
+}
+
+// Update runs fn holding the write lock unless ctx is already done. Nothing
+// is rolled back, so fn must make all of its checks before it changes any
+// record.
+func (s *Store) Update(ctx context.Context, fn func(*Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(&s.tx)
+}
+
+// DepartmentExists reports whether a department with the given ID is stored.
+func (tx *Tx) DepartmentExists(id string) bool {
+	_, ok := tx.Departments[id]
+	return ok
+}
+
+// EmployeeIDsByDepartment returns the IDs of the employees assigned to the
+// department, in ID order.
+func (tx *Tx) EmployeeIDsByDepartment(deptID string) []string {
+	var ids []string
+	for id, e := range tx.Employees {
+		if e.DepartmentId == deptID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// DepartmentIDsByParent returns the IDs of the departments directly under
+// the parent, in ID order.
+func (tx *Tx) DepartmentIDsByParent(parentID string) []string {
+	var ids []string
+	for id, d := range tx.Departments {
+		if d.ParentID == parentID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// DepartmentSubtree returns id followed by the IDs of every department below
+// it, level by level.
+func (tx *Tx) DepartmentSubtree(id string) []string {
+	ids := []string{id}
+	seen := map[string]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range tx.DepartmentIDsByParent(ids[i]) {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+// EmployeeIDsByManager returns the IDs of the employees reporting directly
+// to the manager, in ID order.
+func (tx *Tx) EmployeeIDsByManager(managerID string) []string {
+	var ids []string
+	for id, e := range tx.Employees {
+		if e.ManagerID == managerID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// EmployeeIDByEmail returns the ID of the employee using email, empty when
+// no employee does.
+func (tx *Tx) EmployeeIDByEmail(email string) string {
+	if email == "" {
+		return ""
+	}
+	for id, e := range tx.Employees {
+		if e.Email == email {
+			return id
+		}
+	}
+	return ""
+}
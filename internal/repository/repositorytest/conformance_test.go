@@ -0,0 +1,72 @@
+package repositorytest_test
+
+import (
+	"context"
+	"database/sql"
+	"go.etcd.io/bbolt"
+	"path/filepath"
+	"template-golang/internal/repository/boltdb"
+	"template-golang/internal/repository/department"
+	"template-golang/internal/repository/employee"
+	"template-golang/internal/repository/memory"
+	"template-golang/internal/repository/repositorytest"
+	"template-golang/internal/repository/sqldb"
+	"template-golang/internal/repository/sqldb/sqldbtest"
+	"testing"
+)
+
+func TestBolt(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := boltdb.EnsureBuckets(db); err != nil {
+			t.Fatal(err)
+		}
+		if err := boltdb.Migrate(db, boltdb.DefaultMigrationBatch); err != nil {
+			t.Fatal(err)
+		}
+		if err := boltdb.EnsureIndexes(db); err != nil {
+			t.Fatal(err)
+		}
+		return repositorytest.Repositories{
+			Employees:   employee.NewBoltRepository(db),
+			Departments: department.NewBoltRepository(db),
+		}
+	})
+}
+
+func TestMemory(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		store := memory.NewStore()
+		return repositorytest.Repositories{
+			Employees:   employee.NewMemoryRepository(store),
+			Departments: department.NewMemoryRepository(store),
+		}
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		return sqlRepositories(t, sqldbtest.SQLite(t), sqldb.SQLite)
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		return sqlRepositories(t, sqldbtest.Postgres(t), sqldb.Postgres)
+	})
+}
+
+func sqlRepositories(t *testing.T, db *sql.DB, d sqldb.Dialect) repositorytest.Repositories {
+	t.Helper()
+	if _, err := sqldb.MigrateUp(context.Background(), db, d); err != nil {
+		t.Fatal(err)
+	}
+	return repositorytest.Repositories{
+		Employees:   employee.NewSQLRepository(db, d),
+		Departments: department.NewSQLRepository(db, d),
+	}
+}
@@ -0,0 +1,845 @@
+// Package repositorytest holds the behaviour every implementation of the
+// employee and department repositories must share.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	domainDept "template-golang/internal/domain/department"
+	domainEmployee "template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	"template-golang/internal/repository/department"
+	"template-golang/internal/repository/employee"
+	"testing"
+)
+
+// Repositories are the repositories of one backend, sharing the same data.
+type Repositories struct {
+	Employees   employee.Repository
+	Departments department.Repository
+}
+
+// Run runs the conformance suite. open is called by every test and must
+// return repositories over an empty store.
+func Run(t *testing.T, open func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r Repositories)
+	}{
+		{"DepartmentLifecycle", testDepartmentLifecycle},
+		{"EmployeeLifecycle", testEmployeeLifecycle},
+		{"EmployeeDepartmentMustExist", testEmployeeDepartmentMustExist},
+		{"EmployeesByDepartment", testEmployeesByDepartment},
+		{"ListEmployees", testListEmployees},
+		{"ListDepartments", testListDepartments},
+		{"DeletePolicies", testDeletePolicies},
+		{"ReportingLines", testReportingLines},
+		{"ManagerChecks", testManagerChecks},
+		{"DepartmentTree", testDepartmentTree},
+		{"ParentChecks", testParentChecks},
+		{"DepartmentHeads", testDepartmentHeads},
+		{"EmployeeProfile", testEmployeeProfile},
+		{"EmployeeStatus", testEmployeeStatus},
+		{"ConcurrentWrites", testConcurrentWrites},
+		{"CancelledContext", testCancelledContext},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+func testDepartmentLifecycle(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	created, err := r.Departments.CreateDepartment(ctx, domainDept.Department{ID: "eng", Name: "Engineering"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Version != 1 {
+		t.Errorf("created version = %d, want 1", created.Version)
+	}
+	_, err = r.Departments.CreateDepartment(ctx, domainDept.Department{ID: "eng", Name: "Other"})
+	expectKind(t, err, errs.ErrConflict)
+
+	got, err := r.Departments.GetDepartmentByID(ctx, "eng")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if *got != *created {
+		t.Errorf("get = %+v, want %+v", *got, *created)
+	}
+	_, err = r.Departments.GetDepartmentByID(ctx, "missing")
+	expectKind(t, err, errs.ErrNotFound)
+
+	updated, err := r.Departments.UpdateDepartmentByID(ctx, "eng", version.Matching(1), domainDept.Department{Name: "R&D"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Name != "R&D" || updated.Version != 2 {
+		t.Errorf("update = %+v, want name R&D at version 2", *updated)
+	}
+	_, err = r.Departments.UpdateDepartmentByID(ctx, "eng", version.Matching(1), domainDept.Department{Name: "Stale"})
+	expectKind(t, err, errs.ErrPrecondition)
+	_, err = r.Departments.UpdateDepartmentByID(ctx, "missing", version.Any(), domainDept.Department{Name: "X"})
+	expectKind(t, err, errs.ErrNotFound)
+
+	patched, err := r.Departments.PatchDepartmentByID(ctx, "eng", version.Any(), func(d domainDept.Department) (domainDept.Department, error) {
+		d.ID = "ignored"
+		d.Name = "Research"
+		return d, nil
+	})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if patched.ID != "eng" || patched.Name != "Research" || patched.Version != 3 {
+		t.Errorf("patch = %+v, want eng named Research at version 3", *patched)
+	}
+	rejected := errs.Validation("rejected")
+	_, err = r.Departments.PatchDepartmentByID(ctx, "eng", version.Any(), func(d domainDept.Department) (domainDept.Department, error) {
+		return d, rejected
+	})
+	if !errors.Is(err, rejected) {
+		t.Errorf("patch error = %v, want the error of apply", err)
+	}
+
+	all, err := r.Departments.GetAllDepartments(ctx)
+	if err != nil {
+		t.Fatalf("get all: %v", err)
+	}
+	if len(all) != 1 || all[0] != *patched {
+		t.Errorf("get all = %+v, want only %+v", all, *patched)
+	}
+
+	expectKind(t, r.Departments.DeleteDepartmentByID(ctx, "eng", version.Matching(1), domainDept.DeleteOptions{}), errs.ErrPrecondition)
+	if err := r.Departments.DeleteDepartmentByID(ctx, "eng", version.Matching(3), domainDept.DeleteOptions{}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = r.Departments.GetDepartmentByID(ctx, "eng")
+	expectKind(t, err, errs.ErrNotFound)
+	expectKind(t, r.Departments.DeleteDepartmentByID(ctx, "eng", version.Any(), domainDept.DeleteOptions{}), errs.ErrNotFound)
+}
+
+func testEmployeeLifecycle(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng", "ops")
+	created, err := r.Employees.CreateEmployee(ctx, domainEmployee.Employee{ID: "e1", Name: "Ada", Position: "Engineer", DepartmentId: "eng"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Version != 1 {
+		t.Errorf("created version = %d, want 1", created.Version)
+	}
+	_, err = r.Employees.CreateEmployee(ctx, domainEmployee.Employee{ID: "e1", Name: "Grace", Position: "Admiral", DepartmentId: "eng"})
+	expectKind(t, err, errs.ErrConflict)
+
+	got, err := r.Employees.GetEmployeeByID(ctx, "e1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if *got != *created {
+		t.Errorf("get = %+v, want %+v", *got, *created)
+	}
+	_, err = r.Employees.GetEmployeeByID(ctx, "missing")
+	expectKind(t, err, errs.ErrNotFound)
+
+	updated, err := r.Employees.UpdateEmployeeByID(ctx, "e1", version.Matching(1), domainEmployee.Employee{Name: "Ada Lovelace", Position: "Analyst", DepartmentId: "ops"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	want := domainEmployee.Employee{ID: "e1", Name: "Ada Lovelace", Position: "Analyst", DepartmentId: "ops", Status: domainEmployee.Active, Version: 2}
+	if *updated != want {
+		t.Errorf("update = %+v, want %+v", *updated, want)
+	}
+	_, err = r.Employees.UpdateEmployeeByID(ctx, "e1", version.Matching(1), want)
+	expectKind(t, err, errs.ErrPrecondition)
+
+	patched, err := r.Employees.PatchEmployeeByID(ctx, "e1", version.Matching(2), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+		e.Position = "Engineer"
+		return e, nil
+	})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if patched.Position != "Engineer" || patched.Name != "Ada Lovelace" || patched.Version != 3 {
+		t.Errorf("patch = %+v, want only the position changed at version 3", *patched)
+	}
+
+	all, err := r.Employees.GetAllEmployees(ctx)
+	if err != nil {
+		t.Fatalf("get all: %v", err)
+	}
+	if len(all) != 1 || all[0] != *patched {
+		t.Errorf("get all = %+v, want only %+v", all, *patched)
+	}
+
+	expectKind(t, r.Employees.DeleteEmployeeByID(ctx, "e1", version.Matching(2)), errs.ErrPrecondition)
+	if err := r.Employees.DeleteEmployeeByID(ctx, "e1", version.Any()); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = r.Employees.GetEmployeeByID(ctx, "e1")
+	expectKind(t, err, errs.ErrNotFound)
+	expectKind(t, r.Employees.DeleteEmployeeByID(ctx, "e1", version.Any()), errs.ErrNotFound)
+}
+
+func testEmployeeDepartmentMustExist(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	_, err := r.Employees.CreateEmployee(ctx, domainEmployee.Employee{ID: "e1", Name: "Ada", Position: "Engineer", DepartmentId: "missing"})
+	expectKind(t, err, errs.ErrValidation)
+
+	createDepartments(t, r, "eng")
+	createEmployee(t, r, "e1", "Ada", "Engineer", "eng")
+	_, err = r.Employees.UpdateEmployeeByID(ctx, "e1", version.Any(), domainEmployee.Employee{Name: "Ada", Position: "Engineer", DepartmentId: "missing"})
+	expectKind(t, err, errs.ErrValidation)
+
+	got, err := r.Employees.GetEmployeeByID(ctx, "e1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.DepartmentId != "eng" || got.Version != 1 {
+		t.Errorf("failed update changed the employee: %+v", *got)
+	}
+}
+
+func testEmployeesByDepartment(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng", "ops")
+	createEmployee(t, r, "e2", "Grace", "Admiral", "eng")
+	createEmployee(t, r, "e1", "Ada", "Engineer", "eng")
+	createEmployee(t, r, "e3", "Linus", "Engineer", "ops")
+
+	expectIDs(t, byDepartment(t, r, "eng"), "e1", "e2")
+	expectIDs(t, byDepartment(t, r, "missing"))
+
+	if _, err := r.Employees.UpdateEmployeeByID(ctx, "e2", version.Any(), domainEmployee.Employee{Name: "Grace", Position: "Admiral", DepartmentId: "ops"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	expectIDs(t, byDepartment(t, r, "eng"), "e1")
+	expectIDs(t, byDepartment(t, r, "ops"), "e2", "e3")
+
+	if err := r.Employees.DeleteEmployeeByID(ctx, "e3", version.Any()); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	expectIDs(t, byDepartment(t, r, "ops"), "e2")
+}
+
+func testListEmployees(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng")
+	createEmployee(t, r, "e1", "carol", "Engineer", "eng")
+	createEmployee(t, r, "e2", "Alice", "Manager", "eng")
+	createEmployee(t, r, "e3", "bob", "engineer", "eng")
+	createEmployee(t, r, "e4", "Alan", "Designer", "eng")
+
+	list := func(q domainEmployee.ListQuery) listing.Page[domainEmployee.Employee] {
+		t.Helper()
+		page, err := r.Employees.ListEmployees(ctx, q)
+		if err != nil {
+			t.Fatalf("list %+v: %v", q, err)
+		}
+		return page
+	}
+	query := func(sort string, limit int, after string) domainEmployee.ListQuery {
+		return domainEmployee.ListQuery{Query: listing.Query{Sort: sort, Limit: limit, After: after}}
+	}
+
+	page := list(query(domainEmployee.SortByName, 3, ""))
+	expectIDs(t, page.Items, "e4", "e2", "e3")
+	if page.Total != 4 || page.NextCursor == "" {
+		t.Fatalf("first page total = %d, cursor = %q; want 4 and a cursor", page.Total, page.NextCursor)
+	}
+	page = list(query(domainEmployee.SortByName, 3, page.NextCursor))
+	expectIDs(t, page.Items, "e1")
+	if page.Total != 4 || page.NextCursor != "" {
+		t.Errorf("last page total = %d, cursor = %q; want 4 and no cursor", page.Total, page.NextCursor)
+	}
+
+	expectIDs(t, list(query(domainEmployee.SortByID, 10, "")).Items, "e1", "e2", "e3", "e4")
+	expectIDs(t, list(query(domainEmployee.SortByPosition, 10, "")).Items, "e4", "e1", "e3", "e2")
+
+	q := query(domainEmployee.SortByName, 10, "")
+	q.Position = "ENGINEER"
+	page = list(q)
+	expectIDs(t, page.Items, "e3", "e1")
+	if page.Total != 2 {
+		t.Errorf("position filter total = %d, want 2", page.Total)
+	}
+
+	q = query(domainEmployee.SortByID, 10, "")
+	q.NamePrefix = "al"
+	expectIDs(t, list(q).Items, "e2", "e4")
+
+	_, err := r.Employees.ListEmployees(ctx, query(domainEmployee.SortByID, 10, page.NextCursor+"!"))
+	expectKind(t, err, errs.ErrValidation)
+	nameCursor := list(query(domainEmployee.SortByName, 1, "")).NextCursor
+	_, err = r.Employees.ListEmployees(ctx, query(domainEmployee.SortByID, 10, nameCursor))
+	expectKind(t, err, errs.ErrValidation)
+}
+
+func testListDepartments(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	for _, d := range []domainDept.Department{{ID: "d1", Name: "Sales"}, {ID: "d2", Name: "engineering"}, {ID: "d3", Name: "Support"}} {
+		if _, err := r.Departments.CreateDepartment(ctx, d); err != nil {
+			t.Fatalf("create %s: %v", d.ID, err)
+		}
+	}
+
+	page, err := r.Departments.ListDepartments(ctx, domainDept.ListQuery{Query: listing.Query{Sort: domainDept.SortByName, Limit: 2}})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	expectIDs(t, page.Items, "d2", "d1")
+	page, err = r.Departments.ListDepartments(ctx, domainDept.ListQuery{Query: listing.Query{Sort: domainDept.SortByName, Limit: 2, After: page.NextCursor}})
+	if err != nil {
+		t.Fatalf("list next page: %v", err)
+	}
+	expectIDs(t, page.Items, "d3")
+	if page.Total != 3 || page.NextCursor != "" {
+		t.Errorf("last page total = %d, cursor = %q; want 3 and no cursor", page.Total, page.NextCursor)
+	}
+
+	page, err = r.Departments.ListDepartments(ctx, domainDept.ListQuery{Query: listing.Query{Sort: domainDept.SortByID, Limit: 10}, NamePrefix: "s"})
+	if err != nil {
+		t.Fatalf("list by prefix: %v", err)
+	}
+	expectIDs(t, page.Items, "d1", "d3")
+}
+
+func testDeletePolicies(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng", "ops", "qa")
+	createEmployee(t, r, "e1", "Ada", "Engineer", "eng")
+	createEmployee(t, r, "e2", "Grace", "Admiral", "eng")
+	createEmployee(t, r, "e3", "Linus", "Engineer", "qa")
+
+	expectKind(t, r.Departments.DeleteDepartmentByID(ctx, "eng", version.Any(), domainDept.DeleteOptions{Policy: domainDept.DeleteReject}), errs.ErrConflict)
+	for _, to := range []string{"eng", "missing"} {
+		opts := domainDept.DeleteOptions{Policy: domainDept.DeleteReassign, ReassignTo: to}
+		expectKind(t, r.Departments.DeleteDepartmentByID(ctx, "eng", version.Any(), opts), errs.ErrValidation)
+	}
+	expectIDs(t, byDepartment(t, r, "eng"), "e1", "e2")
+
+	opts := domainDept.DeleteOptions{Policy: domainDept.DeleteReassign, ReassignTo: "ops"}
+	if err := r.Departments.DeleteDepartmentByID(ctx, "eng", version.Any(), opts); err != nil {
+		t.Fatalf("delete with reassign: %v", err)
+	}
+	moved := byDepartment(t, r, "ops")
+	expectIDs(t, moved, "e1", "e2")
+	for _, e := range moved {
+		if e.Version != 2 {
+			t.Errorf("reassigned employee %s at version %d, want 2", e.ID, e.Version)
+		}
+	}
+
+	// The cascade deletes the reports within the department but not the
+	// manager of those elsewhere, who have to be moved first
+	createReport(t, r, "e4", "e3", "qa")
+	createReport(t, r, "e5", "e3", "ops")
+	cascade := domainDept.DeleteOptions{Policy: domainDept.DeleteCascade}
+	expectKind(t, r.Departments.DeleteDepartmentByID(ctx, "qa", version.Any(), cascade), department.ErrStaffHasReports)
+	expectIDs(t, byDepartment(t, r, "qa"), "e3", "e4")
+	if _, err := r.Employees.PatchEmployeeByID(ctx, "e5", version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+		e.ManagerID = ""
+		return e, nil
+	}); err != nil {
+		t.Fatalf("detach e5: %v", err)
+	}
+	if err := r.Departments.DeleteDepartmentByID(ctx, "qa", version.Any(), cascade); err != nil {
+		t.Fatalf("delete with cascade: %v", err)
+	}
+	for _, id := range []string{"e3", "e4"} {
+		_, err := r.Employees.GetEmployeeByID(ctx, id)
+		expectKind(t, err, errs.ErrNotFound)
+	}
+
+	createDepartments(t, r, "empty")
+	if err := r.Departments.DeleteDepartmentByID(ctx, "empty", version.Any(), domainDept.DeleteOptions{Policy: domainDept.DeleteReassign, ReassignTo: "missing"}); err != nil {
+		t.Errorf("delete of a department without employees: %v", err)
+	}
+}
+
+func testReportingLines(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng", "ops")
+	createEmployee(t, r, "ceo", "Ada", "Chief", "ops")
+	createReport(t, r, "cto", "ceo", "eng")
+	createReport(t, r, "coo", "ceo", "ops")
+	createReport(t, r, "dev1", "cto", "eng")
+	createReport(t, r, "dev2", "cto", "eng")
+	createReport(t, r, "ops1", "cto", "ops")
+
+	direct, err := r.Employees.GetReports(ctx, "ceo", false)
+	if err != nil {
+		t.Fatalf("direct reports: %v", err)
+	}
+	expectIDs(t, direct, "coo", "cto")
+	all, err := r.Employees.GetReports(ctx, "ceo", true)
+	if err != nil {
+		t.Fatalf("all reports: %v", err)
+	}
+	expectIDs(t, all, "coo", "cto", "dev1", "dev2", "ops1")
+	none, err := r.Employees.GetReports(ctx, "dev1", true)
+	if err != nil || none == nil || len(none) != 0 {
+		t.Errorf("reports of dev1 = %v, %v, want an empty list", none, err)
+	}
+	_, err = r.Employees.GetReports(ctx, "missing", false)
+	expectKind(t, err, errs.ErrNotFound)
+
+	chain, err := r.Employees.GetManagementChain(ctx, "dev2")
+	if err != nil {
+		t.Fatalf("chain: %v", err)
+	}
+	expectIDs(t, chain, "cto", "ceo")
+	chain, err = r.Employees.GetManagementChain(ctx, "ceo")
+	if err != nil || chain == nil || len(chain) != 0 {
+		t.Errorf("chain of ceo = %v, %v, want an empty list", chain, err)
+	}
+	_, err = r.Employees.GetManagementChain(ctx, "missing")
+	expectKind(t, err, errs.ErrNotFound)
+
+	// Deleting a department whose staff manage employees elsewhere is
+	// rejected until they are moved
+	cascade := domainDept.DeleteOptions{Policy: domainDept.DeleteCascade}
+	expectKind(t, r.Departments.DeleteDepartmentByID(ctx, "eng", version.Any(), cascade), department.ErrStaffHasReports)
+	if _, err := r.Employees.PatchEmployeeByID(ctx, "ops1", version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+		e.ManagerID = "coo"
+		return e, nil
+	}); err != nil {
+		t.Fatalf("move ops1 under coo: %v", err)
+	}
+	if err := r.Departments.DeleteDepartmentByID(ctx, "eng", version.Any(), cascade); err != nil {
+		t.Fatalf("delete with cascade: %v", err)
+	}
+	all, err = r.Employees.GetReports(ctx, "ceo", true)
+	if err != nil {
+		t.Fatalf("reports after cascade: %v", err)
+	}
+	expectIDs(t, all, "coo", "ops1")
+}
+
+func testManagerChecks(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng", "ops")
+	createEmployee(t, r, "e1", "Ada", "Chief", "eng")
+	createReport(t, r, "e2", "e1", "eng")
+	createReport(t, r, "e3", "e2", "ops")
+
+	_, err := r.Employees.CreateEmployee(ctx, domainEmployee.Employee{ID: "e4", Name: "Grace", Position: "Admiral", DepartmentId: "eng", ManagerID: "missing"})
+	expectKind(t, err, employee.ErrManagerNotFound)
+	for _, managerID := range []string{"e1", "e3"} {
+		_, err = r.Employees.PatchEmployeeByID(ctx, "e1", version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+			e.ManagerID = managerID
+			return e, nil
+		})
+		expectKind(t, err, employee.ErrManagementCycle)
+	}
+	update := domainEmployee.Employee{ID: "e1", Name: "Ada", Position: "Chief", DepartmentId: "eng", ManagerID: "e2"}
+	_, err = r.Employees.UpdateEmployeeByID(ctx, "e1", version.Any(), update)
+	expectKind(t, err, employee.ErrManagementCycle)
+
+	// Moving e3 under e1 is fine, and frees e2 to be deleted
+	expectKind(t, r.Employees.DeleteEmployeeByID(ctx, "e2", version.Any()), employee.ErrEmployeeHasReports)
+	moved, err := r.Employees.PatchEmployeeByID(ctx, "e3", version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+		e.ManagerID = "e1"
+		return e, nil
+	})
+	if err != nil {
+		t.Fatalf("move e3 under e1: %v", err)
+	}
+	if moved.ManagerID != "e1" || moved.Version != 2 {
+		t.Errorf("moved = %+v, want manager e1 at version 2", moved)
+	}
+	if err := r.Employees.DeleteEmployeeByID(ctx, "e2", version.Any()); err != nil {
+		t.Errorf("delete of an employee without reports: %v", err)
+	}
+}
+
+func testDepartmentTree(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "corp")
+	createChild(t, r, "eng", "corp")
+	createChild(t, r, "sales", "corp")
+	createChild(t, r, "backend", "eng")
+	createChild(t, r, "frontend", "eng")
+	createEmployee(t, r, "e1", "Ada", "Chief", "corp")
+	createEmployee(t, r, "e2", "Grace", "Engineer", "backend")
+	createEmployee(t, r, "e3", "Linus", "Engineer", "eng")
+	createEmployee(t, r, "e4", "Ken", "Seller", "sales")
+
+	children, err := r.Departments.GetChildren(ctx, "corp", false)
+	if err != nil {
+		t.Fatalf("children: %v", err)
+	}
+	expectIDs(t, children, "eng", "sales")
+	all, err := r.Departments.GetChildren(ctx, "corp", true)
+	if err != nil {
+		t.Fatalf("all children: %v", err)
+	}
+	expectIDs(t, all, "eng", "sales", "backend", "frontend")
+	none, err := r.Departments.GetChildren(ctx, "backend", true)
+	if err != nil || none == nil || len(none) != 0 {
+		t.Errorf("children of backend = %v, %v, want an empty list", none, err)
+	}
+	_, err = r.Departments.GetChildren(ctx, "missing", false)
+	expectKind(t, err, errs.ErrNotFound)
+
+	staff, err := r.Employees.GetAllEmployeesByDepartmentTree(ctx, "eng")
+	if err != nil {
+		t.Fatalf("staff of eng: %v", err)
+	}
+	expectIDs(t, staff, "e2", "e3")
+	staff, err = r.Employees.GetAllEmployeesByDepartmentTree(ctx, "corp")
+	if err != nil {
+		t.Fatalf("staff of corp: %v", err)
+	}
+	expectIDs(t, staff, "e1", "e2", "e3", "e4")
+
+	// Moving eng under sales takes its subtree along
+	update := domainDept.Department{ID: "eng", Name: "Engineering", ParentID: "sales"}
+	moved, err := r.Departments.UpdateDepartmentByID(ctx, "eng", version.Any(), update)
+	if err != nil {
+		t.Fatalf("move eng: %v", err)
+	}
+	if moved.ParentID != "sales" || moved.Version != 2 {
+		t.Errorf("moved = %+v, want parent sales at version 2", moved)
+	}
+	staff, err = r.Employees.GetAllEmployeesByDepartmentTree(ctx, "sales")
+	if err != nil {
+		t.Fatalf("staff of sales: %v", err)
+	}
+	expectIDs(t, staff, "e2", "e3", "e4")
+}
+
+func testParentChecks(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "corp")
+	createChild(t, r, "eng", "corp")
+	createChild(t, r, "backend", "eng")
+
+	_, err := r.Departments.CreateDepartment(ctx, domainDept.Department{ID: "ops", Name: "Ops", ParentID: "missing"})
+	expectKind(t, err, department.ErrParentNotFound)
+	for _, parentID := range []string{"eng", "backend"} {
+		_, err = r.Departments.PatchDepartmentByID(ctx, "corp", version.Any(), func(d domainDept.Department) (domainDept.Department, error) {
+			d.ParentID = parentID
+			return d, nil
+		})
+		expectKind(t, err, department.ErrDepartmentCycle)
+	}
+
+	for _, policy := range []domainDept.DeletePolicy{domainDept.DeleteReject, domainDept.DeleteCascade} {
+		err = r.Departments.DeleteDepartmentByID(ctx, "eng", version.Any(), domainDept.DeleteOptions{Policy: policy})
+		expectKind(t, err, department.ErrDepartmentHasChildren)
+	}
+	if err := r.Departments.DeleteDepartmentByID(ctx, "backend", version.Any(), domainDept.DeleteOptions{}); err != nil {
+		t.Fatalf("delete of a leaf department: %v", err)
+	}
+	if err := r.Departments.DeleteDepartmentByID(ctx, "eng", version.Any(), domainDept.DeleteOptions{}); err != nil {
+		t.Errorf("delete once the children are gone: %v", err)
+	}
+}
+
+func testDepartmentHeads(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng", "ops")
+	createEmployee(t, r, "e1", "Ada", "Chief", "eng")
+	createEmployee(t, r, "e2", "Grace", "Engineer", "eng")
+	createEmployee(t, r, "e3", "Ken", "Operator", "ops")
+
+	_, err := r.Departments.CreateDepartment(ctx, domainDept.Department{ID: "qa", Name: "QA", HeadID: "missing"})
+	expectKind(t, err, department.ErrHeadNotFound)
+	for headID, want := range map[string]error{"missing": department.ErrHeadNotFound, "e3": department.ErrHeadNotInDepartment} {
+		_, err = r.Departments.PatchDepartmentByID(ctx, "eng", version.Any(), func(d domainDept.Department) (domainDept.Department, error) {
+			d.HeadID = headID
+			return d, nil
+		})
+		expectKind(t, err, want)
+	}
+	setHead := func(deptID, headID string) {
+		t.Helper()
+		if _, err := r.Departments.PatchDepartmentByID(ctx, deptID, version.Any(), func(d domainDept.Department) (domainDept.Department, error) {
+			d.HeadID = headID
+			return d, nil
+		}); err != nil {
+			t.Fatalf("set head of %s: %v", deptID, err)
+		}
+	}
+	expectHead := func(deptID, headID string, v int64) {
+		t.Helper()
+		got, err := r.Departments.GetDepartmentByID(ctx, deptID)
+		if err != nil {
+			t.Fatalf("get %s: %v", deptID, err)
+		}
+		if got.HeadID != headID || got.Version != v {
+			t.Errorf("%s = %+v, want head %q at version %d", deptID, got, headID, v)
+		}
+	}
+	setHead("eng", "e1")
+	expectHead("eng", "e1", 2)
+
+	// Deleting the head, or moving it to another department, leaves the
+	// department without one at a new version
+	if err := r.Employees.DeleteEmployeeByID(ctx, "e1", version.Any()); err != nil {
+		t.Fatalf("delete head: %v", err)
+	}
+	expectHead("eng", "", 3)
+	setHead("eng", "e2")
+	if _, err := r.Employees.PatchEmployeeByID(ctx, "e2", version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+		e.DepartmentId = "ops"
+		return e, nil
+	}); err != nil {
+		t.Fatalf("move head: %v", err)
+	}
+	expectHead("eng", "", 5)
+
+	setHead("ops", "e3")
+	if err := r.Departments.DeleteDepartmentByID(ctx, "ops", version.Any(), domainDept.DeleteOptions{Policy: domainDept.DeleteCascade}); err != nil {
+		t.Fatalf("delete with cascade: %v", err)
+	}
+	_, err = r.Employees.GetEmployeeByID(ctx, "e3")
+	expectKind(t, err, errs.ErrNotFound)
+}
+
+func testEmployeeProfile(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng")
+	profile := domainEmployee.Employee{
+		ID: "e1", Name: "Ada", Position: "Engineer", DepartmentId: "eng",
+		Email: "ada@example.com", Phone: "+44 20 7946 0000",
+		HireDate: "2021-03-01", TerminationDate: "2024-06-30", EmploymentType: domainEmployee.Contractor,
+		Location: "London", CostCenter: "cc-100",
+	}
+	if _, err := r.Employees.CreateEmployee(ctx, profile); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	got, err := r.Employees.GetEmployeeByID(ctx, "e1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	profile.Status, profile.Version = domainEmployee.Active, 1
+	if *got != profile {
+		t.Errorf("stored %+v, want %+v", *got, profile)
+	}
+
+	taken := domainEmployee.Employee{ID: "e2", Name: "Grace", Position: "Admiral", DepartmentId: "eng", Email: "ada@example.com"}
+	_, err = r.Employees.CreateEmployee(ctx, taken)
+	expectKind(t, err, employee.ErrEmailTaken)
+	createEmployee(t, r, "e2", "Grace", "Admiral", "eng")
+	setEmail := func(id, email string) error {
+		_, err := r.Employees.PatchEmployeeByID(ctx, id, version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+			e.Email = email
+			return e, nil
+		})
+		return err
+	}
+	expectKind(t, setEmail("e2", "ada@example.com"), employee.ErrEmailTaken)
+
+	// A replacement without the profile clears it and frees the email, but
+	// keeps the employment dates, which only change through transitions
+	update := domainEmployee.Employee{ID: "e1", Name: "Ada", Position: "Engineer", DepartmentId: "eng", HireDate: "2000-01-01"}
+	got, err = r.Employees.UpdateEmployeeByID(ctx, "e1", version.Any(), update)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got.Email != "" || got.EmploymentType != "" || got.HireDate != "2021-03-01" || got.TerminationDate != "2024-06-30" {
+		t.Errorf("updated = %+v, want no profile but the employment dates", got)
+	}
+	if err := setEmail("e2", "ada@example.com"); err != nil {
+		t.Errorf("reuse of a freed email: %v", err)
+	}
+	if err := r.Employees.DeleteEmployeeByID(ctx, "e2", version.Any()); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := setEmail("e1", "ada@example.com"); err != nil {
+		t.Errorf("reuse of the email of a deleted employee: %v", err)
+	}
+}
+
+func testEmployeeStatus(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createDepartments(t, r, "eng")
+	createEmployee(t, r, "e1", "Ada", "Engineer", "eng")
+	createEmployee(t, r, "e2", "Grace", "Engineer", "eng")
+	candidate := domainEmployee.Employee{ID: "e3", Name: "Linus", Position: "Engineer", DepartmentId: "eng", Status: domainEmployee.Candidate}
+	if _, err := r.Employees.CreateEmployee(ctx, candidate); err != nil {
+		t.Fatalf("create candidate: %v", err)
+	}
+
+	terminated, err := r.Employees.PatchEmployeeByID(ctx, "e2", version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+		return e, e.Apply(domainEmployee.Terminate, "2024-06-30", "Moved abroad")
+	})
+	if err != nil {
+		t.Fatalf("terminate: %v", err)
+	}
+	got, err := r.Employees.GetEmployeeByID(ctx, "e2")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if *got != *terminated || got.Status != domainEmployee.Terminated || got.StatusDate != "2024-06-30" || got.StatusReason != "Moved abroad" {
+		t.Errorf("stored %+v, want %+v", *got, *terminated)
+	}
+
+	// A replacement leaves the status alone
+	update := domainEmployee.Employee{ID: "e2", Name: "Grace", Position: "Admiral", DepartmentId: "eng", Status: domainEmployee.Active}
+	if got, err = r.Employees.UpdateEmployeeByID(ctx, "e2", version.Any(), update); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got.Status != domainEmployee.Terminated || got.StatusReason != "Moved abroad" {
+		t.Errorf("updated = %+v, want the status kept", got)
+	}
+
+	for status, want := range map[domainEmployee.Status][]string{
+		domainEmployee.Active:     {"e1"},
+		domainEmployee.Candidate:  {"e3"},
+		domainEmployee.Terminated: {"e2"},
+		domainEmployee.OnLeave:    {},
+	} {
+		q := domainEmployee.ListQuery{Query: listing.Query{Sort: domainEmployee.SortByName, Limit: 10}, Status: status}
+		page, err := r.Employees.ListEmployees(ctx, q)
+		if err != nil {
+			t.Fatalf("list %s: %v", status, err)
+		}
+		expectIDs(t, page.Items, want...)
+	}
+
+	// Transitions cannot go back in time
+	transition := func(id string, to domainEmployee.Transition, date string) error {
+		_, err := r.Employees.PatchEmployeeByID(ctx, id, version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+			return e, e.Apply(to, date, "")
+		})
+		return err
+	}
+	expectKind(t, transition("e2", domainEmployee.Rehire, "2024-06-29"), errs.ErrValidation)
+	hired := domainEmployee.Employee{ID: "e4", Name: "Ken", Position: "Engineer", DepartmentId: "eng", HireDate: "2024-05-01"}
+	if _, err := r.Employees.CreateEmployee(ctx, hired); err != nil {
+		t.Fatalf("create e4: %v", err)
+	}
+	expectKind(t, transition("e4", domainEmployee.Terminate, "2024-04-30"), errs.ErrValidation)
+	if err := transition("e4", domainEmployee.Terminate, "2024-05-01"); err != nil {
+		t.Errorf("terminate on the hire date: %v", err)
+	}
+}
+
+func testConcurrentWrites(t *testing.T, r Repositories) {
+	createDepartments(t, r, "eng")
+	createEmployee(t, r, "e1", "Ada", "Engineer", "eng")
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Employees.PatchEmployeeByID(context.Background(), "e1", version.Any(), func(e domainEmployee.Employee) (domainEmployee.Employee, error) {
+				return e, nil
+			})
+			if err != nil {
+				t.Errorf("patch: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := r.Employees.GetEmployeeByID(context.Background(), "e1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Version != writers+1 {
+		t.Errorf("version after %d patches = %d, want %d", writers, got.Version, writers+1)
+	}
+}
+
+func testCancelledContext(t *testing.T, r Repositories) {
+	createDepartments(t, r, "eng")
+	createEmployee(t, r, "e1", "Ada", "Engineer", "eng")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.Employees.ListEmployees(ctx, domainEmployee.ListQuery{Query: listing.Query{Sort: domainEmployee.SortByID, Limit: 10}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("list error = %v, want context.Canceled", err)
+	}
+	_, err = r.Employees.CreateEmployee(ctx, domainEmployee.Employee{ID: "e2", Name: "Grace", Position: "Admiral", DepartmentId: "eng"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("create error = %v, want context.Canceled", err)
+	}
+	if _, err := r.Employees.GetEmployeeByID(context.Background(), "e2"); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("employee created with a cancelled context: %v", err)
+	}
+}
+
+func createDepartments(t *testing.T, r Repositories, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if _, err := r.Departments.CreateDepartment(context.Background(), domainDept.Department{ID: id, Name: "Department " + id}); err != nil {
+			t.Fatalf("create department %s: %v", id, err)
+		}
+	}
+}
+
+func createChild(t *testing.T, r Repositories, id, parentID string) {
+	t.Helper()
+	d := domainDept.Department{ID: id, Name: "Department " + id, ParentID: parentID}
+	if _, err := r.Departments.CreateDepartment(context.Background(), d); err != nil {
+		t.Fatalf("create department %s: %v", id, err)
+	}
+}
+
+func createEmployee(t *testing.T, r Repositories, id, name, position, deptID string) {
+	t.Helper()
+	e := domainEmployee.Employee{ID: id, Name: name, Position: position, DepartmentId: deptID}
+	if _, err := r.Employees.CreateEmployee(context.Background(), e); err != nil {
+		t.Fatalf("create employee %s: %v", id, err)
+	}
+}
+
+func createReport(t *testing.T, r Repositories, id, managerID, deptID string) {
+	t.Helper()
+	e := domainEmployee.Employee{ID: id, Name: "Employee " + id, Position: "Staff", DepartmentId: deptID, ManagerID: managerID}
+	if _, err := r.Employees.CreateEmployee(context.Background(), e); err != nil {
+		t.Fatalf("create employee %s: %v", id, err)
+	}
+}
+
+func byDepartment(t *testing.T, r Repositories, deptID string) []domainEmployee.Employee {
+	t.Helper()
+	employees, err := r.Employees.GetAllEmployeesByDepartmentID(context.Background(), deptID)
+	if err != nil {
+		t.Fatalf("employees of %s: %v", deptID, err)
+	}
+	return employees
+}
+
+func expectKind(t *testing.T, err, kind error) {
+	t.Helper()
+	if !errors.Is(err, kind) {
+		t.Errorf("error = %v, want %v", err, kind)
+	}
+}
+
+func expectIDs[T interface {
+	domainEmployee.Employee | domainDept.Department
+}](t *testing.T, items []T, ids ...string) {
+	t.Helper()
+	got := make([]string, len(items))
+	for i, item := range items {
+		switch v := any(item).(type) {
+		case domainEmployee.Employee:
+			got[i] = v.ID
+		case domainDept.Department:
+			got[i] = v.ID
+		}
+	}
+	if len(got) != len(ids) {
+		t.Errorf("IDs = %v, want %v", got, ids)
+		return
+	}
+	for i := range ids {
+		if got[i] != ids[i] {
+			t.Errorf("IDs = %v, want %v", got, ids)
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package sqldb
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"strconv"
+	"strings"
+)
+
+// Dialect holds what differs between the SQL databases the repositories run
+// on. Queries are written with ? placeholders and rebound by the dialect.
+type Dialect struct {
+	Name string
+	// ForUpdate is appended to the SELECT reading a row about to be
+	// modified in the same transaction.
+	ForUpdate string
+	// ForNoKeyUpdate replaces ForUpdate when the key of the row is kept, so
+	// other transactions can still lock it with ForShare.
+	ForNoKeyUpdate string
+	// ForShare is appended to the SELECT checking that a referenced row
+	// exists, so it cannot be deleted before the transaction ends.
+	ForShare string
+	// ForShareRow is appended to the SELECT checking the columns of a
+	// referenced row, so they cannot change before the transaction ends.
+	ForShareRow string
+	// HierarchyLock is run by transactions changing the reporting lines or
+	// the department tree, so two of them cannot close a cycle that neither
+	// sees.
+	HierarchyLock string
+	// SizeQuery selects the size of the database in bytes.
+	SizeQuery string
+	// MigrationLock is run first by migrating transactions so concurrent
+	// instances apply each migration once.
+	MigrationLock string
+
+	numbered              bool
+	isUniqueViolation     func(error) bool
+	isForeignKeyViolation func(error) bool
+}
+
+// SQLite serializes writers with BEGIN IMMEDIATE, so rows read in a write
+// transaction need no lock.
+var SQLite = Dialect{
+	Name:      "sqlite",
+	SizeQuery: `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`,
+	isUniqueViolation: func(err error) bool {
+		code := sqliteCode(err)
+		return code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || code == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	},
+	isForeignKeyViolation: func(err error) bool {
+		return sqliteCode(err) == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+	},
+}
+
+// Postgres locks the rows read by write transactions, which run at the
+// default read committed isolation level.
+var Postgres = Dialect{
+	Name:           "postgres",
+	ForUpdate:      " FOR UPDATE",
+	ForNoKeyUpdate: " FOR NO KEY UPDATE",
+	ForShare:       " FOR KEY SHARE",
+	ForShareRow:    " FOR SHARE",
+	HierarchyLock:  `SELECT pg_advisory_xact_lock(4243)`,
+	SizeQuery:      `SELECT pg_database_size(current_database())`,
+	MigrationLock:  `SELECT pg_advisory_xact_lock(4242)`,
+	numbered:       true,
+	isUniqueViolation: func(err error) bool {
+		return postgresCode(err) == "23505"
+	},
+	isForeignKeyViolation: func(err error) bool {
+		return postgresCode(err) == "23503"
+	},
+}
+
+// Rebind rewrites the ? placeholders of query for the dialect.
+func (d Dialect) Rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// IsUniqueViolation reports whether err was caused by a duplicate key.
+func (d Dialect) IsUniqueViolation(err error) bool {
+	return err != nil && d.isUniqueViolation(err)
+}
+
+// IsForeignKeyViolation reports whether err was caused by a reference to a
+// missing row, or by deleting a row still referenced.
+func (d Dialect) IsForeignKeyViolation(err error) bool {
+	return err != nil && d.isForeignKeyViolation(err)
+}
+
+func sqliteCode(err error) int {
+	var e *sqlite.Error
+	if errors.As(err, &e) {
+		return e.Code()
+	}
+	return 0
+}
+
+func postgresCode(err error) string {
+	var e *pgconn.PgError
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
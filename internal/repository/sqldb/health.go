@@ -0,0 +1,63 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/health"
+)
+
+// tables maps the record tables to the names their key counts are reported
+// under, shared with the other backends.
+var tables = map[string]string{
+	"employees":   "Employees",
+	"departments": "Departments",
+}
+
+// Health probes a SQL database for the health endpoints.
+type Health struct {
+	db      *sql.DB
+	dialect Dialect
+	path    string
+}
+
+// NewHealth returns the probe of db. path is reported as the location of the
+// database and may be empty.
+func NewHealth(db *sql.DB, d Dialect, path string) *Health {
+	return &Health{db: db, dialect: d, path: path}
+}
+
+// Ready checks that a read transaction succeeds and sees every table.
+func (h *Health) Ready() error {
+	return View(context.Background(), h.db, h.dialect, func(tx *Tx) error {
+		for table := range tables {
+			if _, err := Exists(context.Background(), tx, `SELECT 1 FROM `+table+` LIMIT 1`); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (h *Health) Storage() (health.Storage, error) {
+	storage := health.Storage{
+		Backend: h.dialect.Name,
+		Path:    h.path,
+		Keys:    map[string]int{},
+	}
+	ctx := context.Background()
+	err := View(ctx, h.db, h.dialect, func(tx *Tx) error {
+		if err := tx.QueryRowContext(ctx, h.dialect.SizeQuery).Scan(&storage.SizeBytes); err != nil {
+			return err
+		}
+		for table, name := range tables {
+			var n int
+			if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table).Scan(&n); err != nil {
+				return err
+			}
+			storage.Keys[name] = n
+		}
+		return nil
+	})
+	return storage, errs.Storage(err)
+}
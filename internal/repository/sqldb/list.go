@@ -0,0 +1,87 @@
+package sqldb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+)
+
+// Listing describes the rows walked by List. Rows are ordered by SortColumn
+// then id, or by id alone when SortColumn is empty, and only the rows matching
+// every condition of Where are considered.
+type Listing struct {
+	Table      string
+	Columns    string
+	Where      []string
+	Args       []any
+	SortColumn string
+}
+
+// List returns the page of rows following after, the sort key decoded from
+// the cursor of the previous page. Keys are built like the bbolt sort indexes
+// so every backend pages identically.
+func List[T any](ctx context.Context, tx *Tx, l Listing, sort string, after []byte, limit int, scan func(*sql.Rows) (T, error), key func(T) []byte) (listing.Page[T], error) {
+	page := listing.Page[T]{Items: []T{}}
+	where, args := l.Where, l.Args
+
+	count := "SELECT COUNT(*) FROM " + l.Table + clause(where)
+	if err := tx.QueryRowContext(ctx, count, args...).Scan(&page.Total); err != nil {
+		return page, err
+	}
+
+	order := "id"
+	if after != nil {
+		if l.SortColumn == "" {
+			where = append(where, "id > ?")
+			args = append(args, string(after))
+		} else {
+			value, id, ok := bytes.Cut(after, []byte{0})
+			if !ok {
+				return page, errs.Validation("Invalid cursor")
+			}
+			where = append(where, "("+l.SortColumn+" > ? OR ("+l.SortColumn+" = ? AND id > ?))")
+			args = append(args, string(value), string(value), string(id))
+		}
+	}
+	if l.SortColumn != "" {
+		order = l.SortColumn + ", id"
+	}
+
+	query := "SELECT " + l.Columns + " FROM " + l.Table + clause(where) + " ORDER BY " + order + " LIMIT ?"
+	rows, err := tx.QueryContext(ctx, query, append(args, limit+1)...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	last := after
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return page, err
+		}
+		if len(page.Items) == limit {
+			page.NextCursor = listing.EncodeCursor(sort, last)
+			break
+		}
+		page.Items = append(page.Items, item)
+		last = key(item)
+	}
+	return page, rows.Err()
+}
+
+// PrefixPattern returns the LIKE pattern, escaped with \, matching the
+// strings starting with prefix.
+func PrefixPattern(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+func clause(where []string) string {
+	if len(where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(where, " AND ")
+}
@@ -0,0 +1,213 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change, read from the files
+// migrations/<dialect>/<version>_<name>.up.sql and .down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is a migration with the time it was applied, nil while it
+// is pending.
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+const migrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`
+
+// Migrations returns the migrations of the dialect in version order.
+func (d Dialect) Migrations() ([]Migration, error) {
+	dir := path.Join("migrations", d.Name)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		number, name, found := strings.Cut(base, "_")
+		v, err := strconv.Atoi(number)
+		if !ok || !found || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		body, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[v]
+		if m == nil {
+			m = &Migration{Version: v, Name: name}
+			byVersion[v] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// MigrationStatus returns every migration of the dialect with the time it
+// was applied to db.
+func MigrationStatus(ctx context.Context, db *sql.DB, d Dialect) ([]MigrationState, error) {
+	migrations, err := d.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	var states []MigrationState
+	err = Update(ctx, db, d, func(tx *Tx) error {
+		applied, err := appliedMigrations(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			state := MigrationState{Migration: m}
+			if at, ok := applied[m.Version]; ok {
+				state.AppliedAt = &at
+			}
+			states = append(states, state)
+		}
+		return nil
+	})
+	return states, err
+}
+
+// MigrateUp applies the pending migrations in version order, each in its own
+// transaction, and returns the ones it applied.
+func MigrateUp(ctx context.Context, db *sql.DB, d Dialect) ([]Migration, error) {
+	migrations, err := d.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, m := range migrations {
+		var ran bool
+		err := Update(ctx, db, d, func(tx *Tx) error {
+			applied, err := lockMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if _, ok := applied[m.Version]; ok {
+				return nil
+			}
+			if _, err := tx.Tx.ExecContext(ctx, m.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+			ran = true
+			return recordMigration(ctx, tx, m)
+		})
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			done = append(done, m)
+		}
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the latest applied migration and returns it, or nil
+// when no migration is applied.
+func MigrateDown(ctx context.Context, db *sql.DB, d Dialect) (*Migration, error) {
+	migrations, err := d.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	var reverted *Migration
+	err = Update(ctx, db, d, func(tx *Tx) error {
+		applied, err := lockMigrations(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if _, err := tx.Tx.ExecContext(ctx, m.Down); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.Version); err != nil {
+				return err
+			}
+			reverted = &m
+			return nil
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// lockMigrations serializes the migrating transactions of every instance
+// and returns the applied migrations once the lock is held.
+func lockMigrations(ctx context.Context, tx *Tx) (map[int]time.Time, error) {
+	if tx.Dialect.MigrationLock != "" {
+		if _, err := tx.ExecContext(ctx, tx.Dialect.MigrationLock); err != nil {
+			return nil, err
+		}
+	}
+	return appliedMigrations(ctx, tx)
+}
+
+func appliedMigrations(ctx context.Context, tx *Tx) (map[int]time.Time, error) {
+	if _, err := tx.ExecContext(ctx, migrationsTable); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = at
+	}
+	return applied, rows.Err()
+}
+
+func recordMigration(ctx context.Context, tx *Tx, m Migration) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UTC())
+	return err
+}
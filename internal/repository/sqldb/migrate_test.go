@@ -0,0 +1,79 @@
+package sqldb_test
+
+import (
+	"context"
+	"database/sql"
+	"template-golang/internal/repository/sqldb"
+	"template-golang/internal/repository/sqldb/sqldbtest"
+	"testing"
+)
+
+func TestMigrationsSQLite(t *testing.T) {
+	testMigrations(t, sqldbtest.SQLite(t), sqldb.SQLite)
+}
+
+func TestMigrationsPostgres(t *testing.T) {
+	testMigrations(t, sqldbtest.Postgres(t), sqldb.Postgres)
+}
+
+func testMigrations(t *testing.T, db *sql.DB, d sqldb.Dialect) {
+	ctx := context.Background()
+	migrations, err := d.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations")
+	}
+
+	expectApplied := func(want int) {
+		t.Helper()
+		states, err := sqldb.MigrationStatus(ctx, db, d)
+		if err != nil {
+			t.Fatalf("status: %v", err)
+		}
+		applied := 0
+		for _, s := range states {
+			if s.AppliedAt != nil {
+				applied++
+			}
+		}
+		if len(states) != len(migrations) || applied != want {
+			t.Fatalf("status reports %d of %d migrations applied, want %d of %d", applied, len(states), want, len(migrations))
+		}
+	}
+
+	expectApplied(0)
+	applied, err := sqldb.MigrateUp(ctx, db, d)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("up applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	expectApplied(len(migrations))
+	if _, err := db.Exec(`INSERT INTO departments (id, name, name_key, version) VALUES ('eng', 'Eng', 'eng', 1)`); err != nil {
+		t.Fatalf("schema not usable after up: %v", err)
+	}
+
+	if applied, err = sqldb.MigrateUp(ctx, db, d); err != nil || len(applied) != 0 {
+		t.Fatalf("second up applied %d migrations, err %v; want none", len(applied), err)
+	}
+
+	for i := len(migrations); i > 0; i-- {
+		reverted, err := sqldb.MigrateDown(ctx, db, d)
+		if err != nil {
+			t.Fatalf("down: %v", err)
+		}
+		if reverted == nil || reverted.Version != migrations[i-1].Version {
+			t.Fatalf("down reverted %+v, want version %d", reverted, migrations[i-1].Version)
+		}
+		expectApplied(i - 1)
+	}
+	if reverted, err := sqldb.MigrateDown(ctx, db, d); err != nil || reverted != nil {
+		t.Fatalf("down with nothing applied reverted %+v, err %v", reverted, err)
+	}
+	if _, err := sqldb.MigrateUp(ctx, db, d); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+-- Sort keys are the lowered name and position, computed by the application so
+-- rows are ordered exactly like the other backends order them. Keys and IDs
+-- use the C collation to compare bytewise.
+CREATE TABLE departments (
+    id       TEXT COLLATE "C" PRIMARY KEY,
+    name     TEXT NOT NULL,
+    name_key TEXT COLLATE "C" NOT NULL,
+    version  BIGINT NOT NULL
+);
+CREATE INDEX departments_name_key ON departments (name_key, id);
+
+CREATE TABLE employees (
+    id            TEXT COLLATE "C" PRIMARY KEY,
+    name          TEXT NOT NULL,
+    name_key      TEXT COLLATE "C" NOT NULL,
+    position      TEXT NOT NULL,
+    position_key  TEXT COLLATE "C" NOT NULL,
+    department_id TEXT COLLATE "C" NOT NULL REFERENCES departments (id),
+    version       BIGINT NOT NULL
+);
+CREATE INDEX employees_department_id ON employees (department_id, id);
+CREATE INDEX employees_name_key ON employees (name_key, id);
+CREATE INDEX employees_position_key ON employees (position_key, id);
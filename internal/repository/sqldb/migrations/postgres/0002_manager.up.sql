@@ -0,0 +1,2 @@
+ALTER TABLE employees ADD COLUMN manager_id TEXT COLLATE "C" REFERENCES employees (id);
+CREATE INDEX employees_manager_id ON employees (manager_id, id);
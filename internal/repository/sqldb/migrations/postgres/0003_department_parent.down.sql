@@ -0,0 +1 @@
+ALTER TABLE departments DROP COLUMN parent_id;
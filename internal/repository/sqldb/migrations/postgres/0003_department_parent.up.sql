@@ -0,0 +1,2 @@
+ALTER TABLE departments ADD COLUMN parent_id TEXT COLLATE "C" REFERENCES departments (id);
+CREATE INDEX departments_parent_id ON departments (parent_id, id);
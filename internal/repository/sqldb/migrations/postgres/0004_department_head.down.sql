@@ -0,0 +1 @@
+ALTER TABLE departments DROP COLUMN head_id;
@@ -0,0 +1,2 @@
+ALTER TABLE departments ADD COLUMN head_id TEXT COLLATE "C" REFERENCES employees (id);
+CREATE INDEX departments_head_id ON departments (head_id);
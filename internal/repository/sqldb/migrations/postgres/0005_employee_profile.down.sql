@@ -0,0 +1,8 @@
+ALTER TABLE employees
+    DROP COLUMN email,
+    DROP COLUMN phone,
+    DROP COLUMN hire_date,
+    DROP COLUMN termination_date,
+    DROP COLUMN employment_type,
+    DROP COLUMN location,
+    DROP COLUMN cost_center;
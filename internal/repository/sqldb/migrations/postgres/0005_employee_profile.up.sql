@@ -0,0 +1,10 @@
+-- Dates are kept as YYYY-MM-DD text, the way the other backends store them.
+ALTER TABLE employees
+    ADD COLUMN email            TEXT COLLATE "C",
+    ADD COLUMN phone            TEXT,
+    ADD COLUMN hire_date        TEXT COLLATE "C",
+    ADD COLUMN termination_date TEXT COLLATE "C",
+    ADD COLUMN employment_type  TEXT,
+    ADD COLUMN location         TEXT,
+    ADD COLUMN cost_center      TEXT;
+CREATE UNIQUE INDEX employees_email ON employees (email);
@@ -0,0 +1,4 @@
+ALTER TABLE employees
+    DROP COLUMN status,
+    DROP COLUMN status_date,
+    DROP COLUMN status_reason;
@@ -0,0 +1,6 @@
+-- Employees stored before the lifecycle existed are active.
+ALTER TABLE employees
+    ADD COLUMN status        TEXT COLLATE "C" NOT NULL DEFAULT 'active',
+    ADD COLUMN status_date   TEXT COLLATE "C",
+    ADD COLUMN status_reason TEXT;
+CREATE INDEX employees_status ON employees (status, id);
@@ -0,0 +1,23 @@
+-- Sort keys are the lowered name and position, computed by the application so
+-- rows are ordered exactly like the other backends order them.
+-- IF NOT EXISTS adopts files created before migrations were tracked.
+CREATE TABLE IF NOT EXISTS departments (
+    id       TEXT PRIMARY KEY,
+    name     TEXT NOT NULL,
+    name_key TEXT NOT NULL,
+    version  INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS departments_name_key ON departments (name_key, id);
+
+CREATE TABLE IF NOT EXISTS employees (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL,
+    name_key      TEXT NOT NULL,
+    position      TEXT NOT NULL,
+    position_key  TEXT NOT NULL,
+    department_id TEXT NOT NULL REFERENCES departments (id),
+    version       INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS employees_department_id ON employees (department_id, id);
+CREATE INDEX IF NOT EXISTS employees_name_key ON employees (name_key, id);
+CREATE INDEX IF NOT EXISTS employees_position_key ON employees (position_key, id);
@@ -0,0 +1,2 @@
+DROP INDEX employees_manager_id;
+ALTER TABLE employees DROP COLUMN manager_id;
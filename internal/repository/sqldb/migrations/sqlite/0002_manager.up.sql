@@ -0,0 +1,4 @@
+-- Managers are checked by the repository, since SQLite cannot drop a column
+-- referenced by a foreign key when the migration is reverted.
+ALTER TABLE employees ADD COLUMN manager_id TEXT;
+CREATE INDEX employees_manager_id ON employees (manager_id, id);
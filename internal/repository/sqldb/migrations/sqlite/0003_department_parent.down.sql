@@ -0,0 +1,2 @@
+DROP INDEX departments_parent_id;
+ALTER TABLE departments DROP COLUMN parent_id;
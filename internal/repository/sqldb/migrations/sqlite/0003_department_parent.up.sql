@@ -0,0 +1,3 @@
+-- Parents are checked by the repository, for the same reason as managers.
+ALTER TABLE departments ADD COLUMN parent_id TEXT;
+CREATE INDEX departments_parent_id ON departments (parent_id, id);
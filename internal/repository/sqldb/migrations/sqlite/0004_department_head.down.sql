@@ -0,0 +1,2 @@
+DROP INDEX departments_head_id;
+ALTER TABLE departments DROP COLUMN head_id;
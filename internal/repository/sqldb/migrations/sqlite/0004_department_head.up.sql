@@ -0,0 +1,3 @@
+-- Heads are checked by the repository, for the same reason as managers.
+ALTER TABLE departments ADD COLUMN head_id TEXT;
+CREATE INDEX departments_head_id ON departments (head_id);
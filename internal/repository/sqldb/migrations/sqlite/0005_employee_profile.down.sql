@@ -0,0 +1,8 @@
+DROP INDEX employees_email;
+ALTER TABLE employees DROP COLUMN cost_center;
+ALTER TABLE employees DROP COLUMN location;
+ALTER TABLE employees DROP COLUMN employment_type;
+ALTER TABLE employees DROP COLUMN termination_date;
+ALTER TABLE employees DROP COLUMN hire_date;
+ALTER TABLE employees DROP COLUMN phone;
+ALTER TABLE employees DROP COLUMN email;
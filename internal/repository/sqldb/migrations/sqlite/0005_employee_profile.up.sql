@@ -0,0 +1,9 @@
+-- Dates are kept as YYYY-MM-DD text, the way the other backends store them.
+ALTER TABLE employees ADD COLUMN email TEXT;
+ALTER TABLE employees ADD COLUMN phone TEXT;
+ALTER TABLE employees ADD COLUMN hire_date TEXT;
+ALTER TABLE employees ADD COLUMN termination_date TEXT;
+ALTER TABLE employees ADD COLUMN employment_type TEXT;
+ALTER TABLE employees ADD COLUMN location TEXT;
+ALTER TABLE employees ADD COLUMN cost_center TEXT;
+CREATE UNIQUE INDEX employees_email ON employees (email);
@@ -0,0 +1,4 @@
+DROP INDEX employees_status;
+ALTER TABLE employees DROP COLUMN status_reason;
+ALTER TABLE employees DROP COLUMN status_date;
+ALTER TABLE employees DROP COLUMN status;
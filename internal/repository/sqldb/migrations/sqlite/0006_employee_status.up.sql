@@ -0,0 +1,5 @@
+-- Employees stored before the lifecycle existed are active.
+ALTER TABLE employees ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE employees ADD COLUMN status_date TEXT;
+ALTER TABLE employees ADD COLUMN status_reason TEXT;
+CREATE INDEX employees_status ON employees (status, id);
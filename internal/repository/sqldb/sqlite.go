@@ -0,0 +1,28 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// OpenSQLite opens the SQLite database at path, creating the file when
+// missing. Foreign keys are enforced and writers wait up to busyTimeout for
+// the database lock. The schema is created by MigrateUp.
+func OpenSQLite(path string, busyTimeout time.Duration) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	params.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening sqlite database: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,61 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Tx is a transaction rebinding the placeholders of its queries.
+type Tx struct {
+	*sql.Tx
+	Dialect Dialect
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return tx.Tx.ExecContext(ctx, tx.Dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return tx.Tx.QueryContext(ctx, tx.Dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRowContext(ctx, tx.Dialect.Rebind(query), args...)
+}
+
+// View runs fn in a read-only transaction.
+func View(ctx context.Context, db *sql.DB, d Dialect, fn func(*Tx) error) error {
+	return run(ctx, db, d, &sql.TxOptions{ReadOnly: true}, fn)
+}
+
+// Update runs fn in a read-write transaction, committed only if fn succeeds.
+func Update(ctx context.Context, db *sql.DB, d Dialect, fn func(*Tx) error) error {
+	return run(ctx, db, d, nil, fn)
+}
+
+func run(ctx context.Context, db *sql.DB, d Dialect, opts *sql.TxOptions, fn func(*Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err := fn(&Tx{Tx: tx, Dialect: d}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Exists reports whether query, selecting at most one row, finds one.
+func Exists(ctx context.Context, tx *Tx, query string, args ...any) (bool, error) {
+	var one int
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DepartmentExists reports whether a department with the given ID is stored.
+func DepartmentExists(ctx context.Context, tx *Tx, id string) (bool, error) {
+	return Exists(ctx, tx, `SELECT 1 FROM departments WHERE id = ?`, id)
+}
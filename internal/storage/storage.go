@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.etcd.io/bbolt"
+	"io"
+	"log/slog"
+	"template-golang/internal/config"
+	"template-golang/internal/health"
+	"template-golang/internal/metrics"
+	"template-golang/internal/repository/boltdb"
+	"template-golang/internal/repository/department"
+	"template-golang/internal/repository/employee"
+	"template-golang/internal/repository/memory"
+	"template-golang/internal/repository/sqldb"
+)
+
+// Storage is the backend selected by the configuration, with the
+// repositories and probes built on it.
+type Storage struct {
+	Employees   employee.Repository
+	Departments department.Repository
+	Health      health.Checker
+	// Collectors export statistics of the backend itself.
+	Collectors []prometheus.Collector
+
+	closer io.Closer
+}
+
+// Open opens the backend named by cfg.Storage, preparing it for use.
+func Open(cfg config.Config) (*Storage, error) {
+	switch cfg.Storage {
+	case config.StorageBolt:
+		return openBolt(cfg.Bolt)
+	case config.StorageMemory:
+		store := memory.NewStore()
+		return &Storage{
+			Employees:   employee.NewMemoryRepository(store),
+			Departments: department.NewMemoryRepository(store),
+			Health:      memory.NewHealth(store),
+		}, nil
+	case config.StorageSQLite, config.StoragePostgres:
+		return openSQL(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
+}
+
+// OpenSQL connects to the SQL database named by cfg.Storage without
+// migrating its schema.
+func OpenSQL(cfg config.Config) (*sql.DB, sqldb.Dialect, error) {
+	switch cfg.Storage {
+	case config.StorageSQLite:
+		db, err := sqldb.OpenSQLite(cfg.SQLite.Path, cfg.SQLite.BusyTimeout.Duration())
+		return db, sqldb.SQLite, err
+	case config.StoragePostgres:
+		db, err := sqldb.OpenPostgres(cfg.Postgres.DSN, sqldb.Pool{
+			MaxOpenConns:    cfg.Postgres.MaxOpenConns,
+			MaxIdleConns:    cfg.Postgres.MaxIdleConns,
+			ConnMaxLifetime: cfg.Postgres.ConnMaxLifetime.Duration(),
+			ConnMaxIdleTime: cfg.Postgres.ConnMaxIdleTime.Duration(),
+		})
+		return db, sqldb.Postgres, err
+	default:
+		return nil, sqldb.Dialect{}, fmt.Errorf("storage %q is not a SQL database", cfg.Storage)
+	}
+}
+
+// openSQL applies the pending migrations before handing out repositories,
+// so a new release brings its schema along.
+func openSQL(cfg config.Config) (*Storage, error) {
+	db, d, err := OpenSQL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := sqldb.MigrateUp(context.Background(), db, d)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s schema: %w", d.Name, err)
+	}
+	for _, m := range applied {
+		slog.Info("applied schema migration", "version", m.Version, "name", m.Name)
+	}
+	// The size of a server database is reported without a file path
+	var path string
+	if d.Name == sqldb.SQLite.Name {
+		path = cfg.SQLite.Path
+	}
+	return &Storage{
+		Employees:   employee.NewSQLRepository(db, d),
+		Departments: department.NewSQLRepository(db, d),
+		Health:      sqldb.NewHealth(db, d, path),
+		Collectors:  []prometheus.Collector{collectors.NewDBStatsCollector(db, d.Name)},
+		closer:      db,
+	}, nil
+}
+
+func openBolt(cfg config.BoltConfig) (*Storage, error) {
+	db, err := bbolt.Open(cfg.Path, 0600, &bbolt.Options{Timeout: cfg.OpenTimeout.Duration()})
+	if err != nil {
+		return nil, fmt.Errorf("opening bbolt database: %w", err)
+	}
+	if err := boltdb.EnsureBuckets(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating bbolt buckets: %w", err)
+	}
+	if err := boltdb.Migrate(db, boltdb.DefaultMigrationBatch); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating bbolt database: %w", err)
+	}
+	if err := boltdb.EnsureIndexes(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("building bbolt indexes: %w", err)
+	}
+	return &Storage{
+		Employees:   employee.NewBoltRepository(db),
+		Departments: department.NewBoltRepository(db),
+		Health:      boltdb.NewHealth(db),
+		Collectors:  []prometheus.Collector{metrics.NewBoltCollector(db)},
+		closer:      db,
+	}, nil
+}
+
+// Close releases the backend once no repository is in use anymore.
+func (s *Storage) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
@@ -0,0 +1,413 @@
+// Package transfer copies the departments and employees of one storage
+// backend into another through their repositories, so data can move between
+// any two backends, such as from the bbolt file to PostgreSQL.
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/listing"
+	"template-golang/internal/domain/version"
+	repositoryDept "template-golang/internal/repository/department"
+	repositoryEmployee "template-golang/internal/repository/employee"
+)
+
+// DefaultBatchSize is the number of records read per page when Options
+// leaves it unset.
+const DefaultBatchSize = listing.MaxLimit
+
+// Repositories are the repositories of one backend.
+type Repositories struct {
+	Employees   repositoryEmployee.Repository
+	Departments repositoryDept.Repository
+}
+
+// Options tune a Run.
+type Options struct {
+	BatchSize int
+	// DryRun reads both backends and reports what would be copied without
+	// writing anything.
+	DryRun bool
+	// Checkpoint names the file the progress is saved to after every batch.
+	// A run finding it resumes after the last saved batch, and removes it
+	// once the copy has been verified. Empty disables checkpoints.
+	Checkpoint string
+	// Progress, when set, is called after every batch.
+	Progress func(Tally)
+}
+
+// Tally counts the records of one collection.
+type Tally struct {
+	Collection string
+	// Read counts the records read from the source in this run.
+	Read int
+	// Copied counts the records created in the target, or that would be in
+	// a dry run.
+	Copied int
+	// Present counts the records the target already held with the same
+	// content, left by an interrupted run.
+	Present int
+	// Source and Target summarize both backends once the copy is done.
+	// Target is left empty by a dry run.
+	Source, Target Summary
+}
+
+// Summary identifies the content of a collection. The checksum covers every
+// field but the version, which restarts at 1 in the target.
+type Summary struct {
+	Count    int    `json:"count"`
+	Checksum string `json:"checksum"`
+}
+
+// Result reports the departments and employees of a Run. Parents, Managers
+// and Heads count the records whose reference was set once every record it
+// may name was copied; their summaries are left empty, as the checksums of
+// the collections cover them.
+type Result struct {
+	Departments Tally
+	Parents     Tally
+	Employees   Tally
+	Managers    Tally
+	Heads       Tally
+}
+
+// ErrMismatch is returned when the target does not hold the same records as
+// the source after the copy.
+var ErrMismatch = errors.New("target does not match source")
+
+// Run copies every department, then every employee, from src into dst in
+// ID order, setting the parents, managers and heads once the records they
+// name are copied, and verifies that both backends hold the same records. Records
+// already in dst with the same content are skipped, so an interrupted run
+// can be repeated; any other record already in dst fails the run. Versions
+// are not carried over.
+func Run(ctx context.Context, src, dst Repositories, opts Options) (Result, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	cp, err := loadCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return Result{}, err
+	}
+
+	departments := departmentCollection(src.Departments, dst.Departments)
+	employees := employeeCollection(src.Employees, dst.Employees)
+	result := Result{
+		Departments: Tally{Collection: departments.name},
+		Parents:     Tally{Collection: "parents"},
+		Employees:   Tally{Collection: employees.name},
+		Managers:    Tally{Collection: "managers"},
+		Heads:       Tally{Collection: "heads"},
+	}
+	// Departments go first so every employee finds its department. Records
+	// are created without their references, which can name records copied
+	// after them, and linked once those all exist.
+	if err := departments.copy(ctx, opts, &cp.Departments, &cp, &result.Departments); err != nil {
+		return result, err
+	}
+	if err := departments.link(ctx, parentReference(dst.Departments), opts, &cp.Parents, &cp, &result.Parents); err != nil {
+		return result, err
+	}
+	if err := employees.copy(ctx, opts, &cp.Employees, &cp, &result.Employees); err != nil {
+		return result, err
+	}
+	if err := employees.link(ctx, managerReference(dst.Employees), opts, &cp.Managers, &cp, &result.Managers); err != nil {
+		return result, err
+	}
+	if err := departments.link(ctx, headReference(dst.Departments), opts, &cp.Heads, &cp, &result.Heads); err != nil {
+		return result, err
+	}
+
+	if err := departments.verify(ctx, opts, &result.Departments); err != nil {
+		return result, err
+	}
+	if err := employees.verify(ctx, opts, &result.Employees); err != nil {
+		return result, err
+	}
+	if !opts.DryRun {
+		return result, removeCheckpoint(opts.Checkpoint)
+	}
+	return result, nil
+}
+
+// collection is the part of a repository pair a Run works with, for records
+// of type T.
+type collection[T any] struct {
+	name string
+	// list returns the page of the source (or of the target when target is
+	// set) following the cursor after, in ID order.
+	list    func(ctx context.Context, target bool, after string, limit int) (listing.Page[T], error)
+	get     func(ctx context.Context, id string) (*T, error)
+	create  func(ctx context.Context, record T) error
+	id      func(T) string
+	content func(T) T
+	// created strips the content of a record down to what create stores:
+	// everything but its references, which are set by later passes.
+	created func(T) T
+}
+
+// reference is a field of a record naming another record, read by get and
+// written to the target by set.
+type reference[T any] struct {
+	get func(T) string
+	set func(ctx context.Context, id, ref string) error
+}
+
+func departmentCollection(src, dst repositoryDept.Repository) collection[department.Department] {
+	return collection[department.Department]{
+		name: "departments",
+		list: func(ctx context.Context, target bool, after string, limit int) (listing.Page[department.Department], error) {
+			repo := src
+			if target {
+				repo = dst
+			}
+			return repo.ListDepartments(ctx, department.ListQuery{Query: listing.Query{Limit: limit, After: after, Sort: department.SortByID}})
+		},
+		get: dst.GetDepartmentByID,
+		create: func(ctx context.Context, d department.Department) error {
+			_, err := dst.CreateDepartment(ctx, d)
+			return err
+		},
+		id: func(d department.Department) string { return d.ID },
+		content: func(d department.Department) department.Department {
+			d.Version = 0
+			return d
+		},
+		created: func(d department.Department) department.Department {
+			d.ParentID, d.HeadID = "", ""
+			return d
+		},
+	}
+}
+
+func parentReference(dst repositoryDept.Repository) reference[department.Department] {
+	return reference[department.Department]{
+		get: func(d department.Department) string { return d.ParentID },
+		set: func(ctx context.Context, id, parentID string) error {
+			_, err := dst.PatchDepartmentByID(ctx, id, version.Any(), func(d department.Department) (department.Department, error) {
+				d.ParentID = parentID
+				return d, nil
+			})
+			return err
+		},
+	}
+}
+
+func headReference(dst repositoryDept.Repository) reference[department.Department] {
+	return reference[department.Department]{
+		get: func(d department.Department) string { return d.HeadID },
+		set: func(ctx context.Context, id, headID string) error {
+			_, err := dst.PatchDepartmentByID(ctx, id, version.Any(), func(d department.Department) (department.Department, error) {
+				d.HeadID = headID
+				return d, nil
+			})
+			return err
+		},
+	}
+}
+
+func employeeCollection(src, dst repositoryEmployee.Repository) collection[employee.Employee] {
+	return collection[employee.Employee]{
+		name: "employees",
+		list: func(ctx context.Context, target bool, after string, limit int) (listing.Page[employee.Employee], error) {
+			repo := src
+			if target {
+				repo = dst
+			}
+			return repo.ListEmployees(ctx, employee.ListQuery{Query: listing.Query{Limit: limit, After: after, Sort: employee.SortByID}})
+		},
+		get: dst.GetEmployeeByID,
+		create: func(ctx context.Context, e employee.Employee) error {
+			_, err := dst.CreateEmployee(ctx, e)
+			return err
+		},
+		id: func(e employee.Employee) string { return e.ID },
+		content: func(e employee.Employee) employee.Employee {
+			e.Version = 0
+			return e
+		},
+		created: func(e employee.Employee) employee.Employee {
+			e.ManagerID = ""
+			return e
+		},
+	}
+}
+
+func managerReference(dst repositoryEmployee.Repository) reference[employee.Employee] {
+	return reference[employee.Employee]{
+		get: func(e employee.Employee) string { return e.ManagerID },
+		set: func(ctx context.Context, id, managerID string) error {
+			_, err := dst.PatchEmployeeByID(ctx, id, version.Any(), func(e employee.Employee) (employee.Employee, error) {
+				e.ManagerID = managerID
+				return e, nil
+			})
+			return err
+		},
+	}
+}
+
+// copy streams the source into the target one batch at a time, starting
+// after the batch recorded in progress and saving it after every batch.
+func (c collection[T]) copy(ctx context.Context, opts Options, progress *position, cp *checkpoint, tally *Tally) error {
+	for !progress.Done {
+		page, err := c.list(ctx, false, progress.After, opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", c.name, err)
+		}
+		for _, record := range page.Items {
+			tally.Read++
+			present, err := c.present(ctx, record)
+			if err != nil {
+				return err
+			}
+			if present {
+				tally.Present++
+				continue
+			}
+			if !opts.DryRun {
+				if err := c.create(ctx, c.created(record)); err != nil {
+					return fmt.Errorf("copying %s %s: %w", c.name, c.id(record), err)
+				}
+			}
+			tally.Copied++
+		}
+
+		progress.After, progress.Done = page.NextCursor, page.NextCursor == ""
+		if !opts.DryRun {
+			if err := cp.save(opts.Checkpoint); err != nil {
+				return err
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(*tally)
+		}
+	}
+	return nil
+}
+
+// present reports whether the target already holds record, failing when it
+// holds another record with the same ID.
+func (c collection[T]) present(ctx context.Context, record T) (bool, error) {
+	existing, err := c.get(ctx, c.id(record))
+	if errors.Is(err, errs.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading target %s %s: %w", c.name, c.id(record), err)
+	}
+	content := func(record T) T { return c.created(c.content(record)) }
+	want, err := json.Marshal(content(record))
+	if err != nil {
+		return false, err
+	}
+	got, err := json.Marshal(content(*existing))
+	if err != nil {
+		return false, err
+	}
+	if string(want) != string(got) {
+		return false, fmt.Errorf("%s %s already exists in the target with other content: %w", c.name, c.id(record), errs.ErrConflict)
+	}
+	return true, nil
+}
+
+// link sets ref in the target for every source record that has one,
+// starting after the batch recorded in progress and saving it after every
+// batch. Records already holding the same reference are counted as present,
+// and those holding another one fail the run.
+func (c collection[T]) link(ctx context.Context, ref reference[T], opts Options, progress *position, cp *checkpoint, tally *Tally) error {
+	for !progress.Done {
+		page, err := c.list(ctx, false, progress.After, opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", c.name, err)
+		}
+		for _, record := range page.Items {
+			tally.Read++
+			id, name := c.id(record), ref.get(record)
+			if name == "" {
+				continue
+			}
+			existing, err := c.get(ctx, id)
+			switch {
+			case errors.Is(err, errs.ErrNotFound) && opts.DryRun:
+				// Not copied by the dry run
+			case err != nil:
+				return fmt.Errorf("reading target %s %s: %w", c.name, id, err)
+			case ref.get(*existing) == name:
+				tally.Present++
+				continue
+			case ref.get(*existing) != "":
+				return fmt.Errorf("%s %s already references %s in the target: %w", c.name, id, ref.get(*existing), errs.ErrConflict)
+			}
+			if !opts.DryRun {
+				if err := ref.set(ctx, id, name); err != nil {
+					return fmt.Errorf("linking %s %s to %s: %w", c.name, id, name, err)
+				}
+			}
+			tally.Copied++
+		}
+
+		progress.After, progress.Done = page.NextCursor, page.NextCursor == ""
+		if !opts.DryRun {
+			if err := cp.save(opts.Checkpoint); err != nil {
+				return err
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(*tally)
+		}
+	}
+	return nil
+}
+
+// verify summarizes the source and, unless in a dry run, the target, and
+// fails when they differ.
+func (c collection[T]) verify(ctx context.Context, opts Options, tally *Tally) error {
+	var err error
+	if tally.Source, err = c.summarize(ctx, false, opts.BatchSize); err != nil {
+		return fmt.Errorf("summarizing source %s: %w", c.name, err)
+	}
+	if opts.DryRun {
+		return nil
+	}
+	if tally.Target, err = c.summarize(ctx, true, opts.BatchSize); err != nil {
+		return fmt.Errorf("summarizing target %s: %w", c.name, err)
+	}
+	if tally.Source != tally.Target {
+		return fmt.Errorf("%s: source has %d (checksum %s), target has %d (checksum %s): %w",
+			c.name, tally.Source.Count, tally.Source.Checksum, tally.Target.Count, tally.Target.Checksum, ErrMismatch)
+	}
+	return nil
+}
+
+// summarize hashes the JSON encoding of every record, in ID order.
+func (c collection[T]) summarize(ctx context.Context, target bool, batchSize int) (Summary, error) {
+	h := sha256.New()
+	var s Summary
+	after := ""
+	for {
+		page, err := c.list(ctx, target, after, batchSize)
+		if err != nil {
+			return s, err
+		}
+		for _, record := range page.Items {
+			line, err := json.Marshal(c.content(record))
+			if err != nil {
+				return s, err
+			}
+			h.Write(append(line, '\n'))
+			s.Count++
+		}
+		if page.NextCursor == "" {
+			s.Checksum = hex.EncodeToString(h.Sum(nil))
+			return s, nil
+		}
+		after = page.NextCursor
+	}
+}
@@ -0,0 +1,247 @@
+package transfer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"template-golang/internal/domain/department"
+	"template-golang/internal/domain/employee"
+	"template-golang/internal/domain/errs"
+	"template-golang/internal/domain/version"
+	repositoryDept "template-golang/internal/repository/department"
+	repositoryEmployee "template-golang/internal/repository/employee"
+	"template-golang/internal/repository/memory"
+	"template-golang/internal/transfer"
+	"testing"
+)
+
+func newRepositories() transfer.Repositories {
+	store := memory.NewStore()
+	return transfer.Repositories{
+		Employees:   repositoryEmployee.NewMemoryRepository(store),
+		Departments: repositoryDept.NewMemoryRepository(store),
+	}
+}
+
+// seed fills a backend with 3 departments and 10 employees, with versions
+// above 1 so the copy has to ignore them.
+func seed(t *testing.T, r transfer.Repositories) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("d%d", i)
+		if _, err := r.Departments.CreateDepartment(ctx, department.Department{ID: id, Name: "Dept " + id}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Departments.UpdateDepartmentByID(ctx, id, version.Any(), department.Department{Name: "Renamed " + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		e := employee.Employee{ID: fmt.Sprintf("e%02d", i), Name: "Emp", Position: "Dev", DepartmentId: fmt.Sprintf("d%d", i%3)}
+		if _, err := r.Employees.CreateEmployee(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunCopiesAndVerifies(t *testing.T) {
+	src, dst := newRepositories(), newRepositories()
+	seed(t, src)
+
+	batches := 0
+	result, err := transfer.Run(context.Background(), src, dst, transfer.Options{
+		BatchSize: 4,
+		Progress:  func(transfer.Tally) { batches++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Departments.Copied != 3 || result.Employees.Copied != 10 {
+		t.Fatalf("copied %d departments and %d employees, want 3 and 10", result.Departments.Copied, result.Employees.Copied)
+	}
+	if batches != 1+1+3+3+1 {
+		t.Errorf("reported %d batches, want 9", batches)
+	}
+	if result.Employees.Source != result.Employees.Target || result.Employees.Target.Count != 10 {
+		t.Errorf("employee summaries differ: %+v", result.Employees)
+	}
+	got, err := dst.Departments.GetDepartmentByID(context.Background(), "d1")
+	if err != nil || got.Name != "Renamed d1" {
+		t.Errorf("department d1 in target = %+v, %v", got, err)
+	}
+}
+
+func TestRunLinksManagers(t *testing.T) {
+	src, dst := newRepositories(), newRepositories()
+	seed(t, src)
+	// e00 reports to e09, which is copied after it
+	for _, link := range [][2]string{{"e09", "e05"}, {"e00", "e09"}} {
+		_, err := src.Employees.PatchEmployeeByID(context.Background(), link[0], version.Any(), func(e employee.Employee) (employee.Employee, error) {
+			e.ManagerID = link[1]
+			return e, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := transfer.Run(context.Background(), src, dst, transfer.Options{BatchSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Managers.Read != 10 || result.Managers.Copied != 2 {
+		t.Errorf("managers tally = %+v, want 10 read and 2 linked", result.Managers)
+	}
+	chain, err := dst.Employees.GetManagementChain(context.Background(), "e00")
+	if err != nil || len(chain) != 2 || chain[0].ID != "e09" || chain[1].ID != "e05" {
+		t.Errorf("chain of e00 in target = %v, %v, want e09 and e05", chain, err)
+	}
+
+	// Running again finds every record in place
+	result, err = transfer.Run(context.Background(), src, dst, transfer.Options{BatchSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Employees.Present != 10 || result.Managers.Present != 2 || result.Managers.Copied != 0 {
+		t.Errorf("second run reports %+v and %+v, want everything present", result.Employees, result.Managers)
+	}
+}
+
+func TestRunLinksParents(t *testing.T) {
+	src, dst := newRepositories(), newRepositories()
+	seed(t, src)
+	// d0 is contained by d2, which is copied after it
+	_, err := src.Departments.PatchDepartmentByID(context.Background(), "d0", version.Any(), func(d department.Department) (department.Department, error) {
+		d.ParentID = "d2"
+		return d, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := transfer.Run(context.Background(), src, dst, transfer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Parents.Copied != 1 {
+		t.Errorf("parents tally = %+v, want 1 linked", result.Parents)
+	}
+	children, err := dst.Departments.GetChildren(context.Background(), "d2", false)
+	if err != nil || len(children) != 1 || children[0].ID != "d0" {
+		t.Errorf("children of d2 in target = %v, %v, want d0", children, err)
+	}
+}
+
+func TestRunLinksHeads(t *testing.T) {
+	src, dst := newRepositories(), newRepositories()
+	seed(t, src)
+	// The head of d1 is copied after the department
+	_, err := src.Departments.PatchDepartmentByID(context.Background(), "d1", version.Any(), func(d department.Department) (department.Department, error) {
+		d.HeadID = "e04"
+		return d, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := transfer.Run(context.Background(), src, dst, transfer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Heads.Copied != 1 {
+		t.Errorf("heads tally = %+v, want 1 linked", result.Heads)
+	}
+	got, err := dst.Departments.GetDepartmentByID(context.Background(), "d1")
+	if err != nil || got.HeadID != "e04" {
+		t.Errorf("department d1 in target = %+v, %v, want head e04", got, err)
+	}
+}
+
+func TestRunDryRunWritesNothing(t *testing.T) {
+	src, dst := newRepositories(), newRepositories()
+	seed(t, src)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+
+	result, err := transfer.Run(context.Background(), src, dst, transfer.Options{DryRun: true, Checkpoint: checkpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Employees.Copied != 10 || result.Employees.Source.Count != 10 {
+		t.Errorf("dry run reports %+v, want 10 employees to copy", result.Employees)
+	}
+	all, err := dst.Employees.GetAllEmployees(context.Background())
+	if err != nil || len(all) != 0 {
+		t.Errorf("dry run wrote %d employees, err %v", len(all), err)
+	}
+}
+
+// failingEmployees fails every creation after the first n.
+type failingEmployees struct {
+	repositoryEmployee.Repository
+	n int
+}
+
+func (r *failingEmployees) CreateEmployee(ctx context.Context, e employee.Employee) (*employee.Employee, error) {
+	if r.n == 0 {
+		return nil, errors.New("connection lost")
+	}
+	r.n--
+	return r.Repository.CreateEmployee(ctx, e)
+}
+
+func TestRunResumesFromCheckpoint(t *testing.T) {
+	src, dst := newRepositories(), newRepositories()
+	seed(t, src)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+
+	// The second batch of employees fails halfway through.
+	failing := dst
+	failing.Employees = &failingEmployees{Repository: dst.Employees, n: 6}
+	if _, err := transfer.Run(context.Background(), src, failing, transfer.Options{BatchSize: 4, Checkpoint: checkpoint}); err == nil {
+		t.Fatal("interrupted run succeeded")
+	}
+
+	result, err := transfer.Run(context.Background(), src, dst, transfer.Options{BatchSize: 4, Checkpoint: checkpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The departments and the first batch of employees are not read again,
+	// and the records of the interrupted batch are found in the target.
+	if result.Departments.Read != 0 || result.Employees.Read != 6 {
+		t.Errorf("resumed run read %d departments and %d employees, want 0 and 6", result.Departments.Read, result.Employees.Read)
+	}
+	if result.Employees.Present != 2 || result.Employees.Copied != 4 {
+		t.Errorf("resumed run found %d employees and copied %d, want 2 and 4", result.Employees.Present, result.Employees.Copied)
+	}
+	if result.Employees.Target.Count != 10 {
+		t.Errorf("target holds %d employees, want 10", result.Employees.Target.Count)
+	}
+}
+
+func TestRunRejectsDifferentRecords(t *testing.T) {
+	src, dst := newRepositories(), newRepositories()
+	seed(t, src)
+	if _, err := dst.Departments.CreateDepartment(context.Background(), department.Department{ID: "d1", Name: "Other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := transfer.Run(context.Background(), src, dst, transfer.Options{})
+	if !errors.Is(err, errs.ErrConflict) {
+		t.Fatalf("err = %v, want a conflict", err)
+	}
+}
+
+func TestRunDetectsMismatch(t *testing.T) {
+	src, dst := newRepositories(), newRepositories()
+	seed(t, src)
+	if _, err := dst.Departments.CreateDepartment(context.Background(), department.Department{ID: "extra", Name: "Extra"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := transfer.Run(context.Background(), src, dst, transfer.Options{})
+	if !errors.Is(err, transfer.ErrMismatch) {
+		t.Fatalf("err = %v, want a mismatch", err)
+	}
+}
@@ -1,16 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/swaggo/http-swagger"
-	"go.etcd.io/bbolt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"template-golang/internal/app/department"
 	"template-golang/internal/app/employee"
+	"template-golang/internal/app/httpx"
+	"template-golang/internal/config"
+	"template-golang/internal/health"
+	"template-golang/internal/lifecycle"
+	"template-golang/internal/logging"
+	"template-golang/internal/metrics"
 	repositoryDept "template-golang/internal/repository/department"
 	repositoryEmployee "template-golang/internal/repository/employee"
+	"template-golang/internal/storage"
 )
 
 // @title Clean GO API Docs
@@ -21,43 +32,107 @@ import (
 // @host localhost:port
 // @BasePath /
 func main() {
-	db, err := bbolt.Open("my.db", 0600, nil)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(os.Args[2:], os.Stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "transfer" {
+		if err := transferStorage(os.Args[2:], os.Stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, opts, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer func(db *bbolt.DB) {
-		err := db.Close()
+	if opts.PrintConfig {
+		out, err := cfg.YAML()
 		if err != nil {
-			fmt.Println("Error:", err)
+			log.Fatal(err)
 		}
-	}(db)
+		fmt.Print(string(out))
+		return
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
 
-	repo := repositoryEmployee.NewBoltRepository(db)
+	store, err := storage.Open(cfg)
+	if err != nil {
+		fatal("opening storage", err)
+	}
+
+	m := metrics.New()
+	for _, c := range store.Collectors {
+		m.Register(c)
+	}
+
+	repo := repositoryEmployee.NewInstrumentedRepository(store.Employees, m.ObserveRepository("employee"))
 	service := employee.NewService(repo)
 	handler := employee.NewHandler(service)
 
 	r := mux.NewRouter()
+	r.Use(httpx.Timeout(cfg.HTTP.RequestTimeout.Duration()))
 	r.PathPrefix("/swagger").Handler(httpSwagger.WrapHandler)
+	r.Handle(metrics.Path, m.Handler()).Methods("GET")
+
+	//Health
+	healthHandler := health.NewHandler(store.Health)
+	r.HandleFunc(health.Paths.Healthz, healthHandler.Healthz).Methods("GET")
+	r.HandleFunc(health.Paths.Readyz, healthHandler.Readyz).Methods("GET")
+	r.HandleFunc(health.Paths.Status, healthHandler.Status).Methods("GET")
 
 	//Employees
-	r.HandleFunc(employee.Employees.ByID, handler.GetAllEmployees).Methods("GET")
+	r.HandleFunc(employee.Employees.Base, handler.GetAllEmployees).Methods("GET")
 	r.HandleFunc(employee.Employees.ByID, handler.GetEmployeeById).Methods("GET")
 	r.HandleFunc(employee.Employees.ByDepartment, handler.GetAllEmployeesByDepartmentID).Methods("GET")
 	r.HandleFunc(employee.Employees.Base, handler.CreateEmployee).Methods("POST")
 	r.HandleFunc(employee.Employees.ByID, handler.UpdateEmployeeByID).Methods("PUT")
+	r.HandleFunc(employee.Employees.ByID, handler.PatchEmployeeByID).Methods("PATCH")
 	r.HandleFunc(employee.Employees.ByID, handler.DeleteEmployeeByID).Methods("DELETE")
+	r.HandleFunc(employee.Employees.Reports, handler.GetReports).Methods("GET")
+	r.HandleFunc(employee.Employees.Chain, handler.GetManagementChain).Methods("GET")
+	r.HandleFunc(employee.Employees.OrgChart, handler.GetOrgChart).Methods("GET")
+	r.HandleFunc(employee.Employees.Transition, handler.Transition).Methods("POST")
 
-	deptRepo := repositoryDept.NewBoltRepository(db)
-	deptService := department.NewService(deptRepo)
+	deptRepo := repositoryDept.NewInstrumentedRepository(store.Departments, m.ObserveRepository("department"))
+	deptService := department.NewService(deptRepo, repo)
 	deptHandler := department.NewHandler(deptService)
 
-	r.HandleFunc(department.Departments.ByID, deptHandler.GetAllDepartments).Methods("GET")
+	r.HandleFunc(department.Departments.Base, deptHandler.GetAllDepartments).Methods("GET")
 	r.HandleFunc(department.Departments.ByID, deptHandler.GetDepartmentById).Methods("GET")
 	r.HandleFunc(department.Departments.Base, deptHandler.CreateDepartment).Methods("POST")
 	r.HandleFunc(department.Departments.ByID, deptHandler.UpdateDepartmentByID).Methods("PUT")
+	r.HandleFunc(department.Departments.ByID, deptHandler.PatchDepartmentByID).Methods("PATCH")
 	r.HandleFunc(department.Departments.ByID, deptHandler.DeleteDepartmentByID).Methods("DELETE")
+	r.HandleFunc(department.Departments.Children, deptHandler.GetChildren).Methods("GET")
+	r.HandleFunc(department.Departments.Subtree, deptHandler.GetSubtree).Methods("GET")
+	r.HandleFunc(department.Departments.Head, deptHandler.SetHead).Methods("PUT")
 
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatal(err)
+	srv := &http.Server{
+		Addr:         cfg.HTTP.Addr,
+		Handler:      m.Instrument(r, logging.Handler(logger, r)),
+		ReadTimeout:  cfg.HTTP.ReadTimeout.Duration(),
+		WriteTimeout: cfg.HTTP.WriteTimeout.Duration(),
+	}
+	// The server owns the storage from here on and closes it once every
+	// in-flight request has been drained.
+	if err := lifecycle.New(srv, cfg.HTTP.ShutdownTimeout.Duration(), store).Run(context.Background()); err != nil {
+		fatal("running http server", err)
 	}
 }
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"template-golang/internal/config"
+	"template-golang/internal/repository/sqldb"
+	"template-golang/internal/storage"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: template-golang migrate up|down|status [flags]"
+
+// migrate runs the migrate subcommand against the SQL database selected by
+// the configuration flags following the action.
+func migrate(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	action := args[0]
+	cfg, _, err := config.Load(args[1:], os.Getenv)
+	if err != nil {
+		return err
+	}
+	db, d, err := storage.OpenSQL(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		applied, err := sqldb.MigrateUp(ctx, db, d)
+		for _, m := range applied {
+			fmt.Fprintf(stdout, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(stdout, "schema is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := sqldb.MigrateDown(ctx, db, d)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Fprintln(stdout, "no migration to revert")
+			return nil
+		}
+		fmt.Fprintf(stdout, "reverted %04d_%s\n", reverted.Version, reverted.Name)
+		return nil
+	case "status":
+		states, err := sqldb.MigrationStatus(ctx, db, d)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate action %q; %s", action, migrateUsage)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"template-golang/internal/config"
+	"template-golang/internal/storage"
+	"template-golang/internal/transfer"
+	"text/tabwriter"
+)
+
+// transferStorage runs the transfer subcommand, copying the records of the
+// backend selected by the configuration into the one described by the
+// --to configuration file.
+func transferStorage(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("template-golang transfer", flag.ContinueOnError)
+	to := fs.String("to", "", "YAML or JSON configuration file of the target storage (required)")
+	var opts transfer.Options
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "report what would be copied without writing")
+	fs.IntVar(&opts.BatchSize, "batch-size", transfer.DefaultBatchSize, "records read per batch")
+	fs.StringVar(&opts.Checkpoint, "checkpoint", "transfer.checkpoint", "file recording progress to resume from, empty to disable")
+	cfg, _, err := config.LoadFlags(fs, args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	if *to == "" {
+		return errors.New("transfer: --to is required")
+	}
+	// The environment configures the source only.
+	target, _, err := config.Load([]string{"--config", *to}, func(string) string { return "" })
+	if err != nil {
+		return fmt.Errorf("target configuration: %w", err)
+	}
+	if cfg.Storage == config.StorageMemory || target.Storage == config.StorageMemory {
+		return errors.New("transfer: memory storage does not keep records")
+	}
+
+	src, err := storage.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("opening source: %w", err)
+	}
+	defer src.Close()
+	dst, err := storage.Open(target)
+	if err != nil {
+		return fmt.Errorf("opening target: %w", err)
+	}
+	defer dst.Close()
+
+	opts.Progress = func(t transfer.Tally) {
+		fmt.Fprintf(stdout, "%s: read %d, copied %d, already present %d\n", t.Collection, t.Read, t.Copied, t.Present)
+	}
+	result, err := transfer.Run(context.Background(),
+		transfer.Repositories{Employees: src.Employees, Departments: src.Departments},
+		transfer.Repositories{Employees: dst.Employees, Departments: dst.Departments},
+		opts)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COLLECTION\tSOURCE COUNT\tTARGET COUNT\tCHECKSUM")
+	for _, t := range []transfer.Tally{result.Departments, result.Employees} {
+		targetCount := "-"
+		if !opts.DryRun {
+			targetCount = fmt.Sprint(t.Target.Count)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", t.Collection, t.Source.Count, targetCount, t.Source.Checksum)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if opts.DryRun {
+		fmt.Fprintln(stdout, "dry run: nothing was written")
+	} else {
+		fmt.Fprintln(stdout, "target matches source")
+	}
+	return nil
+}